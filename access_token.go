@@ -0,0 +1,213 @@
+package jwt
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+)
+
+// errors
+var (
+	ErrAccessTokenType   = errors.New("access token typ must be at+jwt")
+	ErrInsufficientScope = errors.New("access token does not have the required scope")
+)
+
+// AccessTokenType 是RFC 9068规定的访问令牌typ头部
+const AccessTokenType = "at+jwt"
+
+// ScopeSet 是由scope claim解析出的权限集合
+type ScopeSet map[string]struct{}
+
+// ParseScope 将以空格分隔的scope字符串解析为集合
+func ParseScope(scope string) ScopeSet {
+	set := ScopeSet{}
+	for _, s := range strings.Fields(scope) {
+		set[s] = struct{}{}
+	}
+	return set
+}
+
+// Has 判断集合中是否包含指定的scope
+func (s ScopeSet) Has(scope string) bool {
+	_, ok := s[scope]
+	return ok
+}
+
+// HasAll 判断集合中是否包含所有指定的scope
+func (s ScopeSet) HasAll(scopes ...string) bool {
+	for _, scope := range scopes {
+		if !s.Has(scope) {
+			return false
+		}
+	}
+	return true
+}
+
+// AccessTokenClaims 是RFC 9068定义的OAuth 2.0访问令牌claims
+type AccessTokenClaims struct {
+	Issuer       string       `json:"iss,omitempty"`
+	Subject      string       `json:"sub,omitempty"`
+	Audience     ClaimStrings `json:"aud,omitempty"`
+	ExpiresAt    int64        `json:"exp,omitempty"`
+	IssuedAt     int64        `json:"iat,omitempty"`
+	NotBefore    int64        `json:"nbf,omitempty"`
+	ID           string       `json:"jti,omitempty"`
+	ClientID     string       `json:"client_id,omitempty"`
+	AuthTime     int64        `json:"auth_time,omitempty"`
+	ACR          string       `json:"acr,omitempty"`
+	AMR          []string     `json:"amr,omitempty"`
+	Scope        string       `json:"scope,omitempty"`
+	Groups       []string     `json:"groups,omitempty"`
+	Roles        []string     `json:"roles,omitempty"`
+	Entitlements []string     `json:"entitlements,omitempty"`
+	// Confirmation 是发送方约束令牌(例如DPoP)绑定的密钥
+	Confirmation *Confirmation `json:"cnf,omitempty"`
+}
+
+// Valid 验证访问令牌中与时间相关的claims
+func (c *AccessTokenClaims) Valid() error {
+	vErr := new(ValidationError)
+	now := TimeFunc().UTC().Unix()
+
+	if !verifyExp(c.ExpiresAt, now, false) {
+		delta := time.Unix(now, 0).Sub(time.Unix(c.ExpiresAt, 0))
+		vErr.Inner = fmt.Errorf("token is expired by %v", delta)
+		vErr.Errors |= ValidationErrorExpired
+	}
+
+	if !verifyIat(c.IssuedAt, now, false) {
+		vErr.Inner = fmt.Errorf("token used before issued")
+		vErr.Errors |= ValidationErrorIssuedAt
+	}
+
+	if !verifyNbf(c.NotBefore, now, false) {
+		vErr.Inner = fmt.Errorf("token is not valid yet")
+		vErr.Errors |= ValidationErrorNotValidYet
+	}
+
+	if vErr.valid() {
+		return nil
+	}
+
+	return vErr
+}
+
+// Scopes 返回scope claim解析后的集合
+func (c *AccessTokenClaims) Scopes() ScopeSet {
+	return ParseScope(c.Scope)
+}
+
+// HasScope 判断令牌是否被授予了指定的scope
+func (c *AccessTokenClaims) HasScope(scope string) bool {
+	return c.Scopes().Has(scope)
+}
+
+// HasAllScopes 判断令牌是否被授予了所有指定的scope
+func (c *AccessTokenClaims) HasAllScopes(scopes ...string) bool {
+	return c.Scopes().HasAll(scopes...)
+}
+
+// HasGroup 判断令牌的groups中是否包含指定的组
+func (c *AccessTokenClaims) HasGroup(group string) bool {
+	return containsString(c.Groups, group)
+}
+
+// HasRole 判断令牌的roles中是否包含指定的角色
+func (c *AccessTokenClaims) HasRole(role string) bool {
+	return containsString(c.Roles, role)
+}
+
+// HasEntitlement 判断令牌的entitlements中是否包含指定的权利
+func (c *AccessTokenClaims) HasEntitlement(entitlement string) bool {
+	return containsString(c.Entitlements, entitlement)
+}
+
+func containsString(list []string, s string) bool {
+	for _, v := range list {
+		if v == s {
+			return true
+		}
+	}
+	return false
+}
+
+// AccessTokenValidator 按照RFC 9068验证JWT格式的访问令牌
+type AccessTokenValidator struct {
+	// Issuer 是期望的授权服务器标识，必须与iss完全一致
+	Issuer string
+	// Audience 是资源服务器的标识，必须出现在aud中
+	Audience string
+	// RequiredScopes 是令牌必须具备的scope
+	RequiredScopes []string
+	// Parser 用于转换令牌，为空时使用默认的Parser
+	Parser *Parser
+}
+
+// Parse 转换并验证访问令牌字符串
+func (v *AccessTokenValidator) Parse(tokenString string, keyFunc KeyFunc) (*AccessTokenClaims, error) {
+	return v.ParseContext(context.Background(), tokenString, ContextKeyFunc(keyFunc))
+}
+
+// ParseContext 与Parse相同，ctx会传递给keyFunc
+func (v *AccessTokenValidator) ParseContext(ctx context.Context, tokenString string, keyFunc KeyFuncContext) (*AccessTokenClaims, error) {
+	p := v.Parser
+	if p == nil {
+		p = new(Parser)
+	}
+
+	token, err := p.ParseWithClaimsContext(ctx, tokenString, &AccessTokenClaims{}, keyFunc)
+	if err != nil {
+		return nil, err
+	}
+	return v.Validate(token)
+}
+
+// Validate 验证一个已经通过签名验证的访问令牌
+func (v *AccessTokenValidator) Validate(token *Token) (*AccessTokenClaims, error) {
+	if token == nil || !token.Valid {
+		return nil, NewValidationError("token signature has not been verified", ValidationErrorUnverifiable)
+	}
+
+	typ, _ := token.Header["typ"].(string)
+	if normalizeMediaType(typ) != AccessTokenType {
+		return nil, &ValidationError{Inner: ErrAccessTokenType, Errors: ValidationErrorMalformed}
+	}
+
+	claims, ok := token.Claims.(*AccessTokenClaims)
+	if !ok {
+		return nil, NewValidationError("token claims are not AccessTokenClaims", ValidationErrorClaimsInvalid)
+	}
+
+	if claims.Issuer == "" || !verifyIss(claims.Issuer, v.Issuer, true) {
+		return nil, NewValidationError("access token issuer is invalid", ValidationErrorIssuer)
+	}
+
+	if !claims.Audience.Contains(v.Audience) {
+		return nil, NewValidationError("access token audience is invalid", ValidationErrorAudience)
+	}
+
+	if claims.ExpiresAt == 0 {
+		return nil, NewValidationError("access token exp is missing", ValidationErrorExpired)
+	}
+
+	if claims.IssuedAt == 0 {
+		return nil, NewValidationError("access token iat is missing", ValidationErrorIssuedAt)
+	}
+
+	if claims.ID == "" {
+		return nil, NewValidationError("access token jti is missing", ValidationErrorID)
+	}
+
+	if claims.Subject == "" || claims.ClientID == "" {
+		return nil, NewValidationError("access token sub or client_id is missing", ValidationErrorClaimsInvalid)
+	}
+
+	if !claims.HasAllScopes(v.RequiredScopes...) {
+		return nil, &ValidationError{Inner: ErrInsufficientScope, Errors: ValidationErrorClaimsInvalid}
+	}
+
+	token.Verification.pass(CheckAccessToken)
+	return claims, nil
+}
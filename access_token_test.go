@@ -0,0 +1,123 @@
+package jwt
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/gotoxu/assert"
+)
+
+func validAccessTokenClaims() *AccessTokenClaims {
+	now := time.Now()
+	return &AccessTokenClaims{
+		Issuer:    "https://as.example.com",
+		Subject:   "alice",
+		Audience:  ClaimStrings{"https://api.example.com"},
+		ExpiresAt: now.Add(time.Minute).Unix(),
+		IssuedAt:  now.Unix(),
+		ID:        "dbe39bf3a3ba4238a513f51d6e1691c4",
+		ClientID:  "s6BhdRkqt3",
+		Scope:     "openid profile reademail",
+		Roles:     []string{"admin"},
+	}
+}
+
+func makeAccessToken(claims *AccessTokenClaims, typ string) string {
+	token := NewWithClaims(HS256Method, claims)
+	token.Header["typ"] = typ
+	s, err := token.Generate(hmacTestKey)
+	if err != nil {
+		panic(err.Error())
+	}
+	return s
+}
+
+var accessTokenTestData = []struct {
+	name   string
+	typ    string
+	modify func(c *AccessTokenClaims)
+	scopes []string
+	errors uint32
+}{
+	{"valid", "at+jwt", func(c *AccessTokenClaims) {}, []string{"reademail"}, 0},
+	{"media type typ", "application/AT+JWT", func(c *AccessTokenClaims) {}, nil, 0},
+	{"wrong typ", "JWT", func(c *AccessTokenClaims) {}, nil, ValidationErrorMalformed},
+	{"wrong issuer", "at+jwt", func(c *AccessTokenClaims) { c.Issuer = "https://evil.example.com" }, nil, ValidationErrorIssuer},
+	{"wrong audience", "at+jwt", func(c *AccessTokenClaims) { c.Audience = ClaimStrings{"https://other.example.com"} }, nil, ValidationErrorAudience},
+	{"missing jti", "at+jwt", func(c *AccessTokenClaims) { c.ID = "" }, nil, ValidationErrorID},
+	{"missing client_id", "at+jwt", func(c *AccessTokenClaims) { c.ClientID = "" }, nil, ValidationErrorClaimsInvalid},
+	{"missing scope", "at+jwt", func(c *AccessTokenClaims) {}, []string{"writeemail"}, ValidationErrorClaimsInvalid},
+}
+
+func TestAccessTokenValidator(t *testing.T) {
+	keyFunc := func(*Token) (interface{}, error) { return hmacTestKey, nil }
+
+	for _, data := range accessTokenTestData {
+		claims := validAccessTokenClaims()
+		data.modify(claims)
+
+		v := &AccessTokenValidator{
+			Issuer:         "https://as.example.com",
+			Audience:       "https://api.example.com",
+			RequiredScopes: data.scopes,
+		}
+
+		parsed, err := v.Parse(makeAccessToken(claims, data.typ), keyFunc)
+		if data.errors == 0 {
+			assert.Nil(t, err, data.name)
+			assert.True(t, parsed.HasScope("openid"), data.name)
+			assert.True(t, parsed.HasRole("admin"), data.name)
+			continue
+		}
+
+		assert.NotNil(t, err, data.name)
+		ve, ok := err.(*ValidationError)
+		assert.True(t, ok, data.name)
+		assert.True(t, ve.Errors&data.errors != 0, data.name)
+	}
+}
+
+func TestMiddleware(t *testing.T) {
+	m := &Middleware{
+		KeyFunc: func(*Token) (interface{}, error) { return hmacTestKey, nil },
+		Validator: &AccessTokenValidator{
+			Issuer:   "https://as.example.com",
+			Audience: "https://api.example.com",
+		},
+	}
+
+	handler := m.Handler(RequireScopes("reademail")(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		claims, ok := AccessTokenClaimsFromContext(r.Context())
+		assert.True(t, ok)
+		verification, ok := VerificationFromContext(r.Context())
+		assert.True(t, ok)
+		assert.DeepEqual(t, verification.Algorithm, "HS256")
+		assert.True(t, verification.Passed(CheckSignature))
+		assert.True(t, verification.Passed(CheckAccessToken))
+		w.Write([]byte(claims.Subject))
+	})))
+
+	claims := validAccessTokenClaims()
+
+	req := httptest.NewRequest("GET", "/", nil)
+	req.Header.Set("Authorization", "Bearer "+makeAccessToken(claims, "at+jwt"))
+	rec := httptest.NewRecorder()
+	handler.ServeHTTP(rec, req)
+	assert.DeepEqual(t, rec.Code, http.StatusOK)
+	assert.DeepEqual(t, rec.Body.String(), "alice")
+
+	req = httptest.NewRequest("GET", "/", nil)
+	rec = httptest.NewRecorder()
+	handler.ServeHTTP(rec, req)
+	assert.DeepEqual(t, rec.Code, http.StatusUnauthorized)
+
+	claims.Scope = "openid"
+	req = httptest.NewRequest("GET", "/", nil)
+	req.Header.Set("Authorization", "Bearer "+makeAccessToken(claims, "at+jwt"))
+	rec = httptest.NewRecorder()
+	handler.ServeHTTP(rec, req)
+	assert.DeepEqual(t, rec.Code, http.StatusForbidden)
+	assert.StringContains(t, rec.Header().Get("WWW-Authenticate"), "insufficient_scope")
+}
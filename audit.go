@@ -0,0 +1,219 @@
+package jwt
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"io"
+	"math/rand"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+)
+
+// AuditEventTokenRejected 是被拒绝令牌的审计事件类型
+const AuditEventTokenRejected = "token_rejected"
+
+// maxAuditFieldLength 是审计事件中来自未验证令牌或请求的字符串的最大长度
+const maxAuditFieldLength = 256
+
+// AuditSource 是请求的来源信息，由Middleware通过Context提供给审计日志
+type AuditSource struct {
+	RemoteAddr string `json:"remote_addr,omitempty"`
+	Method     string `json:"method,omitempty"`
+	Path       string `json:"path,omitempty"`
+	UserAgent  string `json:"user_agent,omitempty"`
+	RequestID  string `json:"request_id,omitempty"`
+}
+
+type auditContextKey struct{}
+
+// AuditSourceFromRequest 从HTTP请求中读取来源信息，RequestID取自X-Request-Id头部。
+// 所有字段都由客户端控制，超过maxAuditFieldLength的部分会被截断
+func AuditSourceFromRequest(r *http.Request) *AuditSource {
+	return &AuditSource{
+		RemoteAddr: truncateAuditField(r.RemoteAddr),
+		Method:     truncateAuditField(r.Method),
+		Path:       truncateAuditField(r.URL.Path),
+		UserAgent:  truncateAuditField(r.UserAgent()),
+		RequestID:  truncateAuditField(r.Header.Get("X-Request-Id")),
+	}
+}
+
+// WithAuditSource 返回带有来源信息的Context
+func WithAuditSource(ctx context.Context, source *AuditSource) context.Context {
+	return context.WithValue(ctx, auditContextKey{}, source)
+}
+
+// AuditSourceFromContext 返回WithAuditSource放入Context中的来源信息
+func AuditSourceFromContext(ctx context.Context) (*AuditSource, bool) {
+	source, ok := ctx.Value(auditContextKey{}).(*AuditSource)
+	return source, ok && source != nil
+}
+
+// AuditEvent 是写入审计日志的一行。Issuer、Subject、KeyID和ID来自未经验证的令牌，
+// 不能作为已认证的身份使用。令牌本身和签名从不写入日志
+type AuditEvent struct {
+	Time    time.Time `json:"time"`
+	Event   string    `json:"event"`
+	Reasons []string  `json:"reasons"`
+	Errors  uint32    `json:"errors"`
+	Error   string    `json:"error,omitempty"`
+	// Fingerprint 是头部和载荷(不含签名)的SHA-256摘要的前16字节，用于关联同一令牌的多次拒绝
+	Fingerprint string       `json:"fingerprint,omitempty"`
+	Algorithm   string       `json:"alg,omitempty"`
+	KeyID       string       `json:"kid,omitempty"`
+	Issuer      string       `json:"iss,omitempty"`
+	Subject     string       `json:"sub,omitempty"`
+	ID          string       `json:"jti,omitempty"`
+	Source      *AuditSource `json:"source,omitempty"`
+}
+
+// AuditLogger 将被拒绝的令牌以JSON Lines格式写入Writer，支持采样和限速。
+// 它实现了Hook接口，注册后记录Parser拒绝的所有令牌；也可以直接调用Log。
+// 字段必须在开始使用前设置
+type AuditLogger struct {
+	Writer io.Writer
+	// SampleRate 是记录事件的比例，取值(0, 1]，为0时记录所有事件
+	SampleRate float64
+	// RateLimit 是每秒最多写入的事件数量，为0时不限速
+	RateLimit float64
+	// Burst 是限速时允许的突发数量，为0时为1
+	Burst int
+
+	mu      sync.Mutex
+	tokens  float64
+	last    time.Time
+	dropped uint64
+}
+
+// NewAuditLogger 创建记录所有事件的AuditLogger
+func NewAuditLogger(w io.Writer) *AuditLogger {
+	return &AuditLogger{Writer: w}
+}
+
+// Dropped 返回因采样或限速而没有写入的事件数量
+func (l *AuditLogger) Dropped() uint64 {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	return l.dropped
+}
+
+// OnStart 实现Hook接口
+func (l *AuditLogger) OnStart(ctx context.Context, event HookEvent) {}
+
+// OnEnd 实现Hook接口，记录失败的OpParse事件
+func (l *AuditLogger) OnEnd(ctx context.Context, event HookEvent) {
+	if event.Operation != OpParse || event.Err == nil {
+		return
+	}
+	l.Log(ctx, event.raw, event.Err)
+}
+
+// Log 记录一个被拒绝的令牌，ctx中的AuditSource会一起写入。
+// 事件因采样或限速被丢弃时返回nil
+func (l *AuditLogger) Log(ctx context.Context, tokenString string, err error) error {
+	if !l.allow() {
+		return nil
+	}
+
+	event := &AuditEvent{
+		Time:  TimeFunc().UTC(),
+		Event: AuditEventTokenRejected,
+	}
+	if ve, ok := err.(*ValidationError); ok {
+		event.Errors = ve.Errors
+	}
+	if err != nil {
+		event.Error = truncateAuditField(err.Error())
+	}
+	event.Reasons = failureReasons(HookEvent{Errors: event.Errors})
+	event.Source, _ = AuditSourceFromContext(ctx)
+	event.setToken(tokenString)
+
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	return json.NewEncoder(l.Writer).Encode(event)
+}
+
+// allow 按照采样和限速决定是否记录事件
+func (l *AuditLogger) allow() bool {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	if l.SampleRate > 0 && l.SampleRate < 1 && rand.Float64() >= l.SampleRate {
+		l.dropped++
+		return false
+	}
+
+	if l.RateLimit > 0 {
+		burst := float64(l.Burst)
+		if burst < 1 {
+			burst = 1
+		}
+
+		now := time.Now()
+		if l.last.IsZero() {
+			l.tokens = burst
+		} else {
+			l.tokens += now.Sub(l.last).Seconds() * l.RateLimit
+			if l.tokens > burst {
+				l.tokens = burst
+			}
+		}
+		l.last = now
+
+		if l.tokens < 1 {
+			l.dropped++
+			return false
+		}
+		l.tokens--
+	}
+	return true
+}
+
+// setToken 从未验证的令牌中读取指纹、alg、kid、iss、sub和jti，超过大小限制的令牌只记录指纹
+func (e *AuditEvent) setToken(tokenString string) {
+	parts := strings.Split(tokenString, ".")
+	if len(parts) < 2 {
+		return
+	}
+
+	sum := sha256.Sum256([]byte(parts[0] + "." + parts[1]))
+	e.Fingerprint = hex.EncodeToString(sum[:16])
+
+	if len(tokenString) > DefaultMaxTokenSize {
+		return
+	}
+
+	var header struct {
+		Algorithm string `json:"alg"`
+		KeyID     string `json:"kid"`
+	}
+	if data, err := DecodeSegment(parts[0]); err == nil && json.Unmarshal(data, &header) == nil {
+		e.Algorithm = truncateAuditField(header.Algorithm)
+		e.KeyID = truncateAuditField(header.KeyID)
+	}
+
+	var claims struct {
+		Issuer  string `json:"iss"`
+		Subject string `json:"sub"`
+		ID      string `json:"jti"`
+	}
+	if data, err := DecodeSegment(parts[1]); err == nil && json.Unmarshal(data, &claims) == nil {
+		e.Issuer = truncateAuditField(claims.Issuer)
+		e.Subject = truncateAuditField(claims.Subject)
+		e.ID = truncateAuditField(claims.ID)
+	}
+}
+
+func truncateAuditField(s string) string {
+	if len(s) > maxAuditFieldLength {
+		return s[:maxAuditFieldLength]
+	}
+	return s
+}
@@ -0,0 +1,196 @@
+package jwt
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gotoxu/assert"
+)
+
+func decodeAuditEvents(t *testing.T, buf *bytes.Buffer) []AuditEvent {
+	var events []AuditEvent
+	for _, line := range strings.Split(strings.TrimSpace(buf.String()), "\n") {
+		if line == "" {
+			continue
+		}
+		var event AuditEvent
+		assert.Nil(t, json.Unmarshal([]byte(line), &event))
+		events = append(events, event)
+	}
+	return events
+}
+
+func TestAuditLogger(t *testing.T) {
+	key := []byte("this-is-a-32-byte-hs256-test-key")
+	keyFunc := func(*Token) (interface{}, error) { return key, nil }
+
+	token := NewWithClaims(HS256Method, MapClaims{
+		"iss": "https://as.example.com",
+		"sub": "alice",
+		"jti": "id-1",
+		"exp": time.Now().Add(-time.Minute).Unix(),
+	})
+	token.Header["kid"] = "k1"
+	tokenString, _ := token.Generate(key)
+	parts := strings.Split(tokenString, ".")
+
+	var buf bytes.Buffer
+	logger := NewAuditLogger(&buf)
+	p := &Parser{Hooks: []Hook{logger}}
+
+	ctx := WithAuditSource(context.Background(), &AuditSource{RemoteAddr: "192.0.2.1:1234", RequestID: "req-1"})
+	_, err := p.ParseWithContext(ctx, tokenString, ContextKeyFunc(keyFunc))
+	assert.NotNil(t, err)
+	_, err = p.Parse("a.b", keyFunc)
+	assert.NotNil(t, err)
+
+	// 验证通过的令牌不记录
+	valid, _ := NewWithClaims(HS256Method, MapClaims{}).Generate(key)
+	_, err = p.Parse(valid, keyFunc)
+	assert.Nil(t, err)
+
+	assert.False(t, strings.Contains(buf.String(), parts[2]))
+	assert.False(t, strings.Contains(buf.String(), parts[1]))
+
+	events := decodeAuditEvents(t, &buf)
+	assert.Len(t, events, 2)
+
+	event := events[0]
+	assert.DeepEqual(t, event.Event, AuditEventTokenRejected)
+	assert.DeepEqual(t, event.Reasons, []string{"expired"})
+	assert.DeepEqual(t, event.Errors, ValidationErrorExpired)
+	assert.DeepEqual(t, event.Algorithm, "HS256")
+	assert.DeepEqual(t, event.KeyID, "k1")
+	assert.DeepEqual(t, event.Issuer, "https://as.example.com")
+	assert.DeepEqual(t, event.Subject, "alice")
+	assert.DeepEqual(t, event.ID, "id-1")
+	assert.Len(t, event.Fingerprint, 32)
+	assert.DeepEqual(t, event.Source, &AuditSource{RemoteAddr: "192.0.2.1:1234", RequestID: "req-1"})
+
+	assert.DeepEqual(t, events[1].Reasons, []string{"malformed"})
+	assert.Len(t, events[1].Fingerprint, 32)
+	assert.True(t, events[1].Source == nil)
+}
+
+func TestAuditLoggerLimits(t *testing.T) {
+	var buf bytes.Buffer
+	logger := &AuditLogger{Writer: &buf, RateLimit: 0.001, Burst: 2}
+	for i := 0; i < 5; i++ {
+		assert.Nil(t, logger.Log(context.Background(), "a.b.c", ErrSignatureInvalid))
+	}
+	assert.Len(t, decodeAuditEvents(t, &buf), 2)
+	assert.DeepEqual(t, logger.Dropped(), uint64(3))
+
+	buf.Reset()
+	logger = &AuditLogger{Writer: &buf, SampleRate: 1e-12}
+	for i := 0; i < 5; i++ {
+		logger.Log(context.Background(), "a.b.c", ErrSignatureInvalid)
+	}
+	assert.DeepEqual(t, buf.Len(), 0)
+	assert.DeepEqual(t, logger.Dropped(), uint64(5))
+}
+
+func TestMiddlewareAudit(t *testing.T) {
+	var buf bytes.Buffer
+	m := &Middleware{
+		KeyFunc: func(*Token) (interface{}, error) { return hmacTestKey, nil },
+		Validator: &AccessTokenValidator{
+			Issuer:   "https://as.example.com",
+			Audience: "https://api.example.com",
+		},
+		Audit: NewAuditLogger(&buf),
+	}
+	handler := m.Handler(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+
+	claims := validAccessTokenClaims()
+	claims.Issuer = "https://evil.example.com"
+	req := httptest.NewRequest("GET", "/resource", nil)
+	req.Header.Set("Authorization", "Bearer "+makeAccessToken(claims, "at+jwt"))
+	req.Header.Set("X-Request-Id", "req-2")
+	rec := httptest.NewRecorder()
+	handler.ServeHTTP(rec, req)
+	assert.DeepEqual(t, rec.Code, http.StatusUnauthorized)
+
+	events := decodeAuditEvents(t, &buf)
+	assert.Len(t, events, 1)
+	assert.DeepEqual(t, events[0].Reasons, []string{"issuer"})
+	assert.DeepEqual(t, events[0].Issuer, "https://evil.example.com")
+	assert.DeepEqual(t, events[0].Source.Path, "/resource")
+	assert.DeepEqual(t, events[0].Source.RequestID, "req-2")
+	assert.DeepEqual(t, events[0].Source.Method, "GET")
+
+	// 来自请求的字段都会被截断
+	buf.Reset()
+	req = httptest.NewRequest("GET", "/"+strings.Repeat("a", 1000), nil)
+	req.RemoteAddr = strings.Repeat("1", 1000)
+	req.Header.Set("Authorization", "Bearer "+makeAccessToken(claims, "at+jwt"))
+	handler.ServeHTTP(httptest.NewRecorder(), req)
+	events = decodeAuditEvents(t, &buf)
+	assert.Len(t, events, 1)
+	assert.Len(t, events[0].Source.Path, maxAuditFieldLength)
+	assert.Len(t, events[0].Source.RemoteAddr, maxAuditFieldLength)
+
+	// 没有审计日志和Hook时不构造来源信息
+	m.Audit = nil
+	var found bool
+	handler = m.Handler(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, found = AuditSourceFromContext(r.Context())
+	}))
+	req = httptest.NewRequest("GET", "/resource", nil)
+	req.Header.Set("Authorization", "Bearer "+makeAccessToken(validAccessTokenClaims(), "at+jwt"))
+	rec = httptest.NewRecorder()
+	handler.ServeHTTP(rec, req)
+	assert.DeepEqual(t, rec.Code, http.StatusOK)
+	assert.False(t, found)
+}
+
+func TestVerifierAudit(t *testing.T) {
+	var buf bytes.Buffer
+	key := []byte("this-is-a-32-byte-hs256-test-key")
+	v, err := NewVerifier(IssuerConfig{
+		Issuer:       "https://a.example.com",
+		ValidMethods: []string{"HS256"},
+		KeyFunc:      func(*Token) (interface{}, error) { return key, nil },
+		Leeway:       time.Minute,
+	})
+	assert.Nil(t, err)
+	v.Parser = &Parser{Hooks: []Hook{NewAuditLogger(&buf)}}
+
+	sign := func(claims MapClaims) string {
+		s, err := NewWithClaims(HS256Method, claims).Generate(key)
+		assert.Nil(t, err)
+		return s
+	}
+	now := time.Now()
+	var tests = []struct {
+		name    string
+		token   string
+		reasons []string
+	}{
+		{"untrusted issuer", sign(MapClaims{"iss": "https://evil.example.com"}), []string{"issuer"}},
+		{"expired beyond leeway", sign(MapClaims{"iss": "https://a.example.com", "exp": now.Add(-2 * time.Minute).Unix()}), []string{"expired"}},
+	}
+
+	for _, test := range tests {
+		buf.Reset()
+		_, err := v.Verify(test.token)
+		assert.NotNil(t, err, test.name)
+
+		events := decodeAuditEvents(t, &buf)
+		assert.Len(t, events, 1, test.name)
+		assert.DeepEqual(t, events[0].Reasons, test.reasons, test.name)
+		assert.DeepEqual(t, events[0].Algorithm, "HS256", test.name)
+	}
+
+	// 在Leeway之内的令牌不会被记录
+	buf.Reset()
+	_, err = v.Verify(sign(MapClaims{"iss": "https://a.example.com", "exp": now.Add(-30 * time.Second).Unix()}))
+	assert.Nil(t, err)
+	assert.DeepEqual(t, buf.Len(), 0)
+}
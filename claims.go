@@ -2,6 +2,7 @@ package jwt
 
 import (
 	"crypto/subtle"
+	"encoding/json"
 	"fmt"
 	"time"
 )
@@ -118,3 +119,52 @@ func verifyNbf(nbf int64, now int64, required bool) bool {
 	}
 	return now >= nbf
 }
+
+// ClaimStrings 表示既可以是单个字符串也可以是字符串数组的claim，例如aud
+type ClaimStrings []string
+
+// UnmarshalJSON 同时支持字符串和字符串数组两种格式
+func (s *ClaimStrings) UnmarshalJSON(data []byte) error {
+	var v interface{}
+	if err := json.Unmarshal(data, &v); err != nil {
+		return err
+	}
+
+	switch value := v.(type) {
+	case nil:
+		*s = nil
+	case string:
+		*s = ClaimStrings{value}
+	case []interface{}:
+		vals := make(ClaimStrings, 0, len(value))
+		for _, item := range value {
+			str, ok := item.(string)
+			if !ok {
+				return fmt.Errorf("claim contains a non-string element: %v", item)
+			}
+			vals = append(vals, str)
+		}
+		*s = vals
+	default:
+		return fmt.Errorf("claim must be a string or an array of strings")
+	}
+	return nil
+}
+
+// MarshalJSON 单个元素时编码为字符串，否则编码为数组
+func (s ClaimStrings) MarshalJSON() ([]byte, error) {
+	if len(s) == 1 {
+		return json.Marshal(s[0])
+	}
+	return json.Marshal([]string(s))
+}
+
+// Contains 判断是否包含指定的字符串
+func (s ClaimStrings) Contains(cmp string) bool {
+	for _, v := range s {
+		if subtle.ConstantTimeCompare([]byte(v), []byte(cmp)) != 0 {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,241 @@
+package jwt
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/url"
+	"time"
+)
+
+// errors
+var (
+	ErrClientAssertionType     = errors.New("client assertion type is not jwt-bearer")
+	ErrClientAssertionSubject  = errors.New("client assertion iss and sub must both equal the client_id")
+	ErrClientAssertionLifetime = errors.New("client assertion lifetime is too long")
+	ErrClientAssertionReplay   = errors.New("client assertion has already been used")
+)
+
+// ClientAssertionType 是RFC 7523规定的client_assertion_type
+const ClientAssertionType = "urn:ietf:params:oauth:client-assertion-type:jwt-bearer"
+
+// 客户端断言的默认有效期
+const (
+	DefaultClientAssertionLifetime    = time.Minute
+	DefaultClientAssertionMaxLifetime = 10 * time.Minute
+)
+
+// ClientAssertionClaims 是用于OAuth客户端认证的JWT claims
+type ClientAssertionClaims struct {
+	Issuer    string       `json:"iss"`
+	Subject   string       `json:"sub"`
+	Audience  ClaimStrings `json:"aud"`
+	ExpiresAt int64        `json:"exp"`
+	IssuedAt  int64        `json:"iat,omitempty"`
+	NotBefore int64        `json:"nbf,omitempty"`
+	ID        string       `json:"jti"`
+}
+
+// Valid 验证客户端断言中与时间相关的claims
+func (c *ClientAssertionClaims) Valid() error {
+	vErr := new(ValidationError)
+	now := TimeFunc().UTC().Unix()
+
+	if !verifyExp(c.ExpiresAt, now, true) {
+		vErr.Inner = fmt.Errorf("client assertion is expired or has no exp")
+		vErr.Errors |= ValidationErrorExpired
+	}
+
+	if !verifyIat(c.IssuedAt, now, false) {
+		vErr.Inner = fmt.Errorf("token used before issued")
+		vErr.Errors |= ValidationErrorIssuedAt
+	}
+
+	if !verifyNbf(c.NotBefore, now, false) {
+		vErr.Inner = fmt.Errorf("token is not valid yet")
+		vErr.Errors |= ValidationErrorNotValidYet
+	}
+
+	if vErr.valid() {
+		return nil
+	}
+
+	return vErr
+}
+
+// ClientAssertionBuilder 用于生成private_key_jwt或client_secret_jwt客户端断言
+type ClientAssertionBuilder struct {
+	ClientID      string
+	TokenEndpoint string
+	Method        SigningMethod
+	// Key 是private_key_jwt使用的私钥或client_secret_jwt使用的客户端密钥([]byte)
+	Key interface{}
+	// KeyID 不为空时写入kid头部
+	KeyID string
+	// Lifetime 是断言的有效期，为0时使用DefaultClientAssertionLifetime
+	Lifetime time.Duration
+}
+
+// NewPrivateKeyJWT 创建使用私钥签名的private_key_jwt断言生成器
+func NewPrivateKeyJWT(method SigningMethod, privateKey interface{}, clientID, tokenEndpoint string) *ClientAssertionBuilder {
+	return &ClientAssertionBuilder{
+		ClientID:      clientID,
+		TokenEndpoint: tokenEndpoint,
+		Method:        method,
+		Key:           privateKey,
+	}
+}
+
+// NewClientSecretJWT 创建使用客户端密钥进行HMAC签名的client_secret_jwt断言生成器
+func NewClientSecretJWT(method *HMACMethod, secret []byte, clientID, tokenEndpoint string) *ClientAssertionBuilder {
+	return &ClientAssertionBuilder{
+		ClientID:      clientID,
+		TokenEndpoint: tokenEndpoint,
+		Method:        method,
+		Key:           secret,
+	}
+}
+
+// Build 生成一个新的客户端断言，每次调用都使用新的jti
+func (b *ClientAssertionBuilder) Build() (string, error) {
+	jti, err := newTokenID()
+	if err != nil {
+		return "", err
+	}
+
+	lifetime := b.Lifetime
+	if lifetime <= 0 {
+		lifetime = DefaultClientAssertionLifetime
+	}
+
+	now := TimeFunc()
+	token := NewWithClaims(b.Method, &ClientAssertionClaims{
+		Issuer:    b.ClientID,
+		Subject:   b.ClientID,
+		Audience:  ClaimStrings{b.TokenEndpoint},
+		ExpiresAt: now.Add(lifetime).Unix(),
+		IssuedAt:  now.Unix(),
+		ID:        jti,
+	})
+	if b.KeyID != "" {
+		token.Header["kid"] = b.KeyID
+	}
+	return token.Generate(b.Key)
+}
+
+// FormValues 生成一个新的断言，并返回令牌请求中用于客户端认证的表单参数
+func (b *ClientAssertionBuilder) FormValues() (url.Values, error) {
+	assertion, err := b.Build()
+	if err != nil {
+		return nil, err
+	}
+
+	return url.Values{
+		"client_assertion_type": {ClientAssertionType},
+		"client_assertion":      {assertion},
+	}, nil
+}
+
+// ClientAssertionVerifier 在令牌端点验证客户端断言
+type ClientAssertionVerifier struct {
+	// Audience 是授权服务器接受的aud值，例如令牌端点URL和issuer标识
+	Audience []string
+	// ValidMethods 是允许的签名算法，为空时不限制
+	ValidMethods []string
+	// KeyFunc 根据token.Claims中的iss查找该客户端注册的公钥或密钥
+	KeyFunc KeyFunc
+	// KeyFuncContext 不为空时代替KeyFunc
+	KeyFuncContext KeyFuncContext
+	// MaxLifetime 是exp与iat之间允许的最大间隔，为0时使用DefaultClientAssertionMaxLifetime
+	MaxLifetime time.Duration
+	// Replay 不为空时用于拒绝重复使用的jti
+	Replay ReplayCache
+}
+
+// VerifyForm 从令牌请求的表单参数中读取并验证客户端断言
+func (v *ClientAssertionVerifier) VerifyForm(form url.Values) (*ClientAssertionClaims, error) {
+	return v.VerifyFormContext(context.Background(), form)
+}
+
+// VerifyFormContext 与VerifyForm相同，ctx会传递给密钥查找和Replay
+func (v *ClientAssertionVerifier) VerifyFormContext(ctx context.Context, form url.Values) (*ClientAssertionClaims, error) {
+	if form.Get("client_assertion_type") != ClientAssertionType {
+		return nil, &ValidationError{Inner: ErrClientAssertionType, Errors: ValidationErrorMalformed}
+	}
+
+	claims, err := v.VerifyContext(ctx, form.Get("client_assertion"))
+	if err != nil {
+		return nil, err
+	}
+
+	// 请求中同时提供client_id时必须与断言一致
+	if clientID := form.Get("client_id"); clientID != "" && clientID != claims.Subject {
+		return nil, &ValidationError{Inner: ErrClientAssertionSubject, Errors: ValidationErrorClaimsInvalid}
+	}
+	return claims, nil
+}
+
+// Verify 验证客户端断言并返回其claims，claims.Subject即为已认证的client_id
+func (v *ClientAssertionVerifier) Verify(assertion string) (*ClientAssertionClaims, error) {
+	return v.VerifyContext(context.Background(), assertion)
+}
+
+// VerifyContext 与Verify相同，ctx会传递给密钥查找和Replay
+func (v *ClientAssertionVerifier) VerifyContext(ctx context.Context, assertion string) (*ClientAssertionClaims, error) {
+	keyFunc := v.KeyFuncContext
+	if keyFunc == nil {
+		keyFunc = ContextKeyFunc(v.KeyFunc)
+	}
+
+	p := &Parser{ValidMethods: v.ValidMethods}
+	token, err := p.ParseWithClaimsContext(ctx, assertion, &ClientAssertionClaims{}, keyFunc)
+	if err != nil {
+		return nil, err
+	}
+
+	claims := token.Claims.(*ClientAssertionClaims)
+	if claims.Issuer == "" || claims.Issuer != claims.Subject {
+		return nil, &ValidationError{Inner: ErrClientAssertionSubject, Errors: ValidationErrorIssuer}
+	}
+
+	audienceValid := false
+	for _, aud := range v.Audience {
+		if claims.Audience.Contains(aud) {
+			audienceValid = true
+			break
+		}
+	}
+	if !audienceValid {
+		return nil, NewValidationError("client assertion audience is invalid", ValidationErrorAudience)
+	}
+
+	if claims.ID == "" {
+		return nil, NewValidationError("client assertion jti is missing", ValidationErrorID)
+	}
+
+	maxLifetime := v.MaxLifetime
+	if maxLifetime <= 0 {
+		maxLifetime = DefaultClientAssertionMaxLifetime
+	}
+	// 以秒为单位比较，避免exp过大时乘以time.Second溢出
+	now := TimeFunc().Unix()
+	if claims.ExpiresAt <= 0 {
+		return nil, NewValidationError("client assertion exp is missing", ValidationErrorExpired)
+	}
+	start := claims.IssuedAt
+	if start == 0 {
+		start = now
+	}
+	if start < 0 || start > now || start > claims.ExpiresAt {
+		return nil, NewValidationError("client assertion iat is invalid", ValidationErrorIssuedAt)
+	}
+	if claims.ExpiresAt-start > int64(maxLifetime/time.Second) {
+		return nil, &ValidationError{Inner: ErrClientAssertionLifetime, Errors: ValidationErrorExpired}
+	}
+
+	if v.Replay != nil && replaySeen(ctx, v.Replay, claims.Issuer+":"+claims.ID, time.Unix(claims.ExpiresAt, 0)) {
+		return nil, &ValidationError{Inner: ErrClientAssertionReplay, Errors: ValidationErrorID}
+	}
+
+	return claims, nil
+}
@@ -0,0 +1,119 @@
+package jwt
+
+import (
+	"fmt"
+	"math"
+	"testing"
+	"time"
+
+	"github.com/gotoxu/assert"
+)
+
+const testTokenEndpoint = "https://as.example.com/token"
+
+func TestPrivateKeyJWT(t *testing.T) {
+	privateKey := loadRSAPrivateKeyFromDisk("test/sample_key")
+	publicKey := loadRSAPublicKeyFromDisk("test/sample_key.pub")
+
+	v := &ClientAssertionVerifier{
+		Audience:     []string{testTokenEndpoint},
+		ValidMethods: []string{"RS256"},
+		KeyFunc: func(token *Token) (interface{}, error) {
+			if token.Claims.(*ClientAssertionClaims).Issuer != "client-1" {
+				return nil, ErrJWKNotFound
+			}
+			return publicKey, nil
+		},
+		Replay: NewMemoryReplayCache(),
+	}
+
+	form, err := NewPrivateKeyJWT(RS256, privateKey, "client-1", testTokenEndpoint).FormValues()
+	assert.Nil(t, err)
+
+	claims, err := v.VerifyForm(form)
+	assert.Nil(t, err)
+	assert.DeepEqual(t, claims.Subject, "client-1")
+	assert.True(t, claims.ExpiresAt-claims.IssuedAt <= int64(DefaultClientAssertionLifetime/time.Second))
+
+	// 同一个断言不能使用两次
+	_, err = v.VerifyForm(form)
+	assert.NotNil(t, err)
+
+	form, _ = NewPrivateKeyJWT(RS256, privateKey, "client-2", testTokenEndpoint).FormValues()
+	_, err = v.VerifyForm(form)
+	assert.NotNil(t, err)
+
+	form, _ = NewPrivateKeyJWT(RS256, privateKey, "client-1", "https://other.example.com/token").FormValues()
+	_, err = v.VerifyForm(form)
+	assert.NotNil(t, err)
+
+	builder := NewPrivateKeyJWT(RS256, privateKey, "client-1", testTokenEndpoint)
+	builder.Lifetime = time.Hour
+	assertion, _ := builder.Build()
+	_, err = v.Verify(assertion)
+	assert.NotNil(t, err)
+
+	now := time.Now().Unix()
+	var tests = []struct {
+		name   string
+		iat    int64
+		exp    int64
+		errors uint32
+	}{
+		{"valid", now, now + 60, 0},
+		{"no iat", 0, now + 60, 0},
+		// exp-iat乘以time.Second会溢出为0
+		{"huge exp", now, now + 1<<55, ValidationErrorExpired},
+		{"max exp", now, math.MaxInt64, ValidationErrorExpired},
+		{"no exp", now, 0, ValidationErrorExpired},
+		{"negative exp", now, -1, ValidationErrorExpired},
+		{"future iat", now + 60, now + 120, ValidationErrorIssuedAt},
+		{"negative iat", math.MinInt64, now + 60, ValidationErrorIssuedAt},
+	}
+
+	for i, test := range tests {
+		assertion, err := NewWithClaims(RS256, &ClientAssertionClaims{
+			Issuer:    "client-1",
+			Subject:   "client-1",
+			Audience:  ClaimStrings{testTokenEndpoint},
+			IssuedAt:  test.iat,
+			ExpiresAt: test.exp,
+			ID:        fmt.Sprintf("lifetime-%d", i),
+		}).Generate(privateKey)
+		assert.Nil(t, err, test.name)
+
+		claims, err := v.Verify(assertion)
+		if test.errors == 0 {
+			assert.Nil(t, err, test.name)
+			continue
+		}
+		assert.True(t, claims == nil, test.name)
+		assert.NotNil(t, err, test.name)
+		assert.True(t, err.(*ValidationError).Errors&test.errors != 0, test.name)
+	}
+}
+
+func TestClientSecretJWT(t *testing.T) {
+	v := &ClientAssertionVerifier{
+		Audience: []string{testTokenEndpoint},
+		KeyFunc:  func(token *Token) (interface{}, error) { return hmacTestKey, nil },
+	}
+
+	assertion, err := NewClientSecretJWT(HS256Method, hmacTestKey, "client-1", testTokenEndpoint).Build()
+	assert.Nil(t, err)
+
+	claims, err := v.Verify(assertion)
+	assert.Nil(t, err)
+	assert.DeepEqual(t, claims.Issuer, "client-1")
+
+	token := NewWithClaims(HS256Method, &ClientAssertionClaims{
+		Issuer:    "client-1",
+		Subject:   "someone-else",
+		Audience:  ClaimStrings{testTokenEndpoint},
+		ExpiresAt: time.Now().Add(time.Minute).Unix(),
+		ID:        "1",
+	})
+	assertion, _ = token.Generate(hmacTestKey)
+	_, err = v.Verify(assertion)
+	assert.NotNil(t, err)
+}
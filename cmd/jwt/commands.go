@@ -0,0 +1,279 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io/ioutil"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/blockcdn-go/jwt"
+)
+
+// timeClaims 是解码时按时间格式显示的claims
+var timeClaims = []string{"iat", "nbf", "exp", "auth_time", "updated_at"}
+
+// headerFlags 收集可重复的-header name=value参数
+type headerFlags map[string]string
+
+func (h headerFlags) String() string {
+	return ""
+}
+
+func (h headerFlags) Set(value string) error {
+	i := strings.Index(value, "=")
+	if i <= 0 {
+		return fmt.Errorf("header must be name=value")
+	}
+	h[value[:i]] = value[i+1:]
+	return nil
+}
+
+func (c *command) flagSet(name string) *flag.FlagSet {
+	fs := flag.NewFlagSet("jwt "+name, flag.ContinueOnError)
+	fs.SetOutput(c.stderr)
+	return fs
+}
+
+func (c *command) sign(args []string) int {
+	fs := c.flagSet("sign")
+	alg := fs.String("alg", "", "signing algorithm, one of the registered methods (required)")
+	keyPath := fs.String("key", "", "private key file: PEM, JWK, or raw secret for HMAC (required)")
+	claimsPath := fs.String("claims", "-", `JSON claims file, "-" reads stdin`)
+	kid := fs.String("kid", "", "value of the kid header")
+	headers := headerFlags{}
+	fs.Var(headers, "header", "extra header as name=value, may be repeated")
+	if err := fs.Parse(args); err != nil {
+		return exitUsage
+	}
+
+	if *alg == "" || *keyPath == "" {
+		return c.fail(exitUsage, "sign requires -alg and -key")
+	}
+
+	method := jwt.GetSigningMethod(*alg)
+	if method == nil {
+		return c.fail(exitUsage, "unknown signing method %q", *alg)
+	}
+
+	key, err := loadSigningKey(*keyPath, method)
+	if err != nil {
+		return c.fail(exitError, "%v", err)
+	}
+
+	data, err := c.readInput(*claimsPath)
+	if err != nil {
+		return c.fail(exitError, "%v", err)
+	}
+
+	claims := jwt.MapClaims{}
+	dec := json.NewDecoder(bytes.NewReader(data))
+	dec.UseNumber()
+	if err = dec.Decode(&claims); err != nil {
+		return c.fail(exitError, "invalid claims: %v", err)
+	}
+
+	token := jwt.NewWithClaims(method, claims)
+	for name, value := range headers {
+		token.Header[name] = value
+	}
+	if *kid != "" {
+		token.Header["kid"] = *kid
+	}
+
+	tokenString, err := token.Generate(key)
+	if err != nil {
+		return c.fail(exitError, "%v", err)
+	}
+
+	fmt.Fprintln(c.stdout, tokenString)
+	return exitOK
+}
+
+func (c *command) verify(args []string) int {
+	fs := c.flagSet("verify")
+	keyPath := fs.String("key", "", "verification key file: PEM, JWK, JWKS, or raw secret for HMAC (required)")
+	algs := fs.String("alg", "", "comma separated list of accepted algorithms")
+	iss := fs.String("iss", "", "expected issuer")
+	aud := fs.String("aud", "", "expected audience")
+	sub := fs.String("sub", "", "expected subject")
+	quiet := fs.Bool("q", false, "do not print the claims")
+	if err := fs.Parse(args); err != nil {
+		return exitUsage
+	}
+
+	if *keyPath == "" {
+		return c.fail(exitUsage, "verify requires -key")
+	}
+
+	keys, err := loadVerificationKeys(*keyPath)
+	if err != nil {
+		return c.fail(exitError, "%v", err)
+	}
+
+	tokenString, err := c.readToken(fs.Args())
+	if err != nil {
+		return c.fail(exitError, "%v", err)
+	}
+
+	// 只接受与密钥类型相符的算法，-alg只能进一步缩小范围
+	p := &jwt.Parser{UseJSONNumber: true, ValidMethods: keys.methods()}
+	if *algs != "" {
+		p.ValidMethods = intersect(strings.Split(*algs, ","), p.ValidMethods)
+	}
+
+	token, err := p.Parse(tokenString, keys.KeyFunc)
+	if err != nil {
+		return c.fail(exitInvalid, "invalid token: %v", err)
+	}
+
+	claims := token.Claims.(jwt.MapClaims)
+	if *iss != "" && !claims.VerifyIssuer(*iss, true) {
+		return c.fail(exitInvalid, "invalid token: issuer is not %q", *iss)
+	}
+	if *aud != "" && !audienceContains(claims["aud"], *aud) {
+		return c.fail(exitInvalid, "invalid token: audience does not contain %q", *aud)
+	}
+	if *sub != "" && claims["sub"] != *sub {
+		return c.fail(exitInvalid, "invalid token: subject is not %q", *sub)
+	}
+
+	if !*quiet {
+		c.printJSON(claims)
+	}
+	return exitOK
+}
+
+func (c *command) decode(args []string) int {
+	fs := c.flagSet("decode")
+	if err := fs.Parse(args); err != nil {
+		return exitUsage
+	}
+
+	tokenString, err := c.readToken(fs.Args())
+	if err != nil {
+		return c.fail(exitError, "%v", err)
+	}
+
+	p := &jwt.Parser{UseJSONNumber: true}
+	token, _, err := p.ParseUnverified(tokenString, jwt.MapClaims{})
+	if token == nil || token.Header == nil || token.Claims == nil {
+		return c.fail(exitInvalid, "malformed token: %v", err)
+	}
+
+	fmt.Fprintln(c.stdout, "Header:")
+	c.printJSON(token.Header)
+	fmt.Fprintln(c.stdout, "Claims:")
+	c.printJSON(token.Claims)
+
+	claims, _ := token.Claims.(jwt.MapClaims)
+	c.printTimes(claims)
+
+	status := "signature not verified"
+	if err != nil {
+		status = fmt.Sprintf("%s; %v", status, err)
+	} else if err = claims.Valid(); err != nil {
+		status = fmt.Sprintf("%s; claims invalid: %v", status, err)
+	} else {
+		status += "; claims valid"
+	}
+	fmt.Fprintf(c.stdout, "Status: %s\n", status)
+
+	if err != nil {
+		return exitInvalid
+	}
+	return exitOK
+}
+
+func (c *command) printTimes(claims jwt.MapClaims) {
+	var lines []string
+	now := time.Now()
+	for _, name := range timeClaims {
+		n, ok := claims[name].(json.Number)
+		if !ok {
+			continue
+		}
+		sec, err := n.Int64()
+		if err != nil {
+			continue
+		}
+
+		t := time.Unix(sec, 0).UTC()
+		rel := "ago"
+		d := now.Sub(t)
+		if d < 0 {
+			rel, d = "from now", -d
+		}
+		lines = append(lines, fmt.Sprintf("  %-10s %s (%s %s)", name+":", t.Format(time.RFC3339), d.Truncate(time.Second), rel))
+	}
+
+	if len(lines) > 0 {
+		sort.Strings(lines)
+		fmt.Fprintln(c.stdout, "Times:")
+		fmt.Fprintln(c.stdout, strings.Join(lines, "\n"))
+	}
+}
+
+func (c *command) printJSON(v interface{}) {
+	data, err := json.MarshalIndent(v, "", "  ")
+	if err != nil {
+		fmt.Fprintf(c.stdout, "%v\n", v)
+		return
+	}
+	fmt.Fprintln(c.stdout, string(data))
+}
+
+// readToken 从第一个位置参数读取令牌，没有参数或参数为"-"时从标准输入读取
+func (c *command) readToken(args []string) (string, error) {
+	if len(args) > 0 && args[0] != "-" {
+		return strings.TrimSpace(args[0]), nil
+	}
+
+	data, err := ioutil.ReadAll(c.stdin)
+	if err != nil {
+		return "", err
+	}
+	token := strings.TrimSpace(string(data))
+	if token == "" {
+		return "", fmt.Errorf("no token given")
+	}
+	return token, nil
+}
+
+func (c *command) readInput(path string) ([]byte, error) {
+	if path == "-" {
+		return ioutil.ReadAll(c.stdin)
+	}
+	return ioutil.ReadFile(path)
+}
+
+func audienceContains(aud interface{}, cmp string) bool {
+	switch v := aud.(type) {
+	case string:
+		return v == cmp
+	case []interface{}:
+		for _, a := range v {
+			if a == cmp {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// intersect 返回a中同时出现在b中的元素
+func intersect(a, b []string) []string {
+	result := []string{}
+	for _, x := range a {
+		for _, y := range b {
+			if x == y {
+				result = append(result, x)
+				break
+			}
+		}
+	}
+	return result
+}
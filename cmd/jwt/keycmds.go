@@ -0,0 +1,186 @@
+package main
+
+import (
+	"crypto/elliptic"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"strings"
+
+	"github.com/blockcdn-go/jwt"
+)
+
+var curves = map[string]elliptic.Curve{
+	"P-256": elliptic.P256(),
+	"P-384": elliptic.P384(),
+	"P-521": elliptic.P521(),
+}
+
+func (c *command) keygen(args []string) int {
+	fs := c.flagSet("keygen")
+	typ := fs.String("type", "", "key type: rsa, ec, ed25519 or hmac (required)")
+	bits := fs.Int("bits", jwt.DefaultRSAKeyBits, "RSA modulus size in bits")
+	curve := fs.String("curve", "P-256", "EC curve: P-256, P-384 or P-521")
+	size := fs.Int("size", 32, "HMAC secret size in bytes")
+	format := fs.String("format", "", "output format: pkcs8, pkcs1, sec1, jwk, jwks, raw (hmac only); default pkcs8, raw for hmac")
+	kid := fs.String("kid", "", "kid for jwk output")
+	alg := fs.String("alg", "", "alg for jwk output")
+	out := fs.String("out", "", "output file, default stdout")
+	if err := fs.Parse(args); err != nil {
+		return exitUsage
+	}
+
+	var key interface{}
+	var err error
+	switch strings.ToLower(*typ) {
+	case "rsa":
+		key, err = jwt.GenerateRSAKey(*bits)
+	case "ec":
+		crv, ok := curves[*curve]
+		if !ok {
+			return c.fail(exitUsage, "unsupported curve %q", *curve)
+		}
+		key, err = jwt.GenerateECKey(crv)
+	case "ed25519":
+		key, err = jwt.GenerateEd25519Key()
+	case "hmac":
+		key, err = jwt.GenerateHMACSecret(*size)
+		if *format == "" {
+			*format = "raw"
+		}
+	default:
+		return c.fail(exitUsage, "keygen requires -type rsa, ec, ed25519 or hmac")
+	}
+	if err != nil {
+		return c.fail(exitError, "%v", err)
+	}
+
+	if *format == "" {
+		*format = "pkcs8"
+	}
+	data, err := encodeKey(key, *format, false, *kid, *alg)
+	if err != nil {
+		return c.fail(exitUsage, "%v", err)
+	}
+	return c.writeOutput(*out, data)
+}
+
+func (c *command) convert(args []string) int {
+	fs := c.flagSet("convert")
+	in := fs.String("in", "-", `input key file: PEM, JWK or JWKS, "-" reads stdin`)
+	format := fs.String("format", "", "output format: pkcs8, pkcs1, sec1, pkix, jwk or jwks (required)")
+	public := fs.Bool("public", false, "output only the public half of the key")
+	kid := fs.String("kid", "", "kid for jwk output")
+	alg := fs.String("alg", "", "alg for jwk output")
+	out := fs.String("out", "", "output file, default stdout")
+	if err := fs.Parse(args); err != nil {
+		return exitUsage
+	}
+
+	if *format == "" {
+		return c.fail(exitUsage, "convert requires -format")
+	}
+
+	data, err := c.readInput(*in)
+	if err != nil {
+		return c.fail(exitError, "%v", err)
+	}
+	key, err := parseKey(data)
+	if err != nil {
+		return c.fail(exitError, "%v", err)
+	}
+
+	if data, err = encodeKey(key, *format, *public, *kid, *alg); err != nil {
+		return c.fail(exitError, "%v", err)
+	}
+	return c.writeOutput(*out, data)
+}
+
+func (c *command) thumbprint(args []string) int {
+	fs := c.flagSet("thumbprint")
+	in := fs.String("in", "-", `input key file: PEM, JWK or JWKS, "-" reads stdin`)
+	if err := fs.Parse(args); err != nil {
+		return exitUsage
+	}
+
+	data, err := c.readInput(*in)
+	if err != nil {
+		return c.fail(exitError, "%v", err)
+	}
+	key, err := parseKey(data)
+	if err != nil {
+		return c.fail(exitError, "%v", err)
+	}
+
+	jwk, err := jwt.NewJSONWebKey(publicKey(key))
+	if err != nil {
+		return c.fail(exitError, "%v", err)
+	}
+	thumbprint, err := jwk.Thumbprint()
+	if err != nil {
+		return c.fail(exitError, "%v", err)
+	}
+
+	fmt.Fprintln(c.stdout, thumbprint)
+	return exitOK
+}
+
+// encodeKey 将密钥编码为指定格式，public为true或格式为pkix时只输出公钥部分
+func encodeKey(key interface{}, format string, public bool, kid, alg string) ([]byte, error) {
+	if public {
+		if _, ok := key.([]byte); ok {
+			return nil, fmt.Errorf("hmac secrets have no public half")
+		}
+		key = publicKey(key)
+	}
+
+	switch format {
+	case "raw":
+		secret, ok := key.([]byte)
+		if !ok {
+			return nil, fmt.Errorf("raw format is only available for hmac secrets")
+		}
+		return secret, nil
+	case "pkix":
+		return jwt.MarshalPublicKeyToPEM(key, jwt.PKIX)
+	case "pkcs1", "pkcs8", "sec1":
+		if !isPrivateKey(key) {
+			if format != "pkcs1" {
+				return nil, fmt.Errorf("public keys can only be written as pkix or pkcs1")
+			}
+			return jwt.MarshalPublicKeyToPEM(key, jwt.PKCS1)
+		}
+		return jwt.MarshalPrivateKeyToPEM(key, jwt.PEMFormat(format))
+	case "jwk", "jwks":
+		jwk, err := jwt.NewJSONWebKey(key)
+		if err != nil {
+			return nil, err
+		}
+		jwk.KeyID = kid
+		jwk.Algorithm = alg
+
+		var v interface{} = jwk
+		if format == "jwks" {
+			v = jwt.JSONWebKeySet{Keys: []jwt.JSONWebKey{*jwk}}
+		}
+		data, err := json.MarshalIndent(v, "", "  ")
+		if err != nil {
+			return nil, err
+		}
+		return append(data, '\n'), nil
+	}
+	return nil, fmt.Errorf("unsupported format %q", format)
+}
+
+func (c *command) writeOutput(path string, data []byte) int {
+	if path == "" {
+		c.stdout.Write(data)
+		return exitOK
+	}
+
+	if err := ioutil.WriteFile(path, data, os.FileMode(0600)); err != nil {
+		return c.fail(exitError, "%v", err)
+	}
+	return exitOK
+}
@@ -0,0 +1,178 @@
+package main
+
+import (
+	"bytes"
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/rsa"
+	"errors"
+	"fmt"
+	"io/ioutil"
+
+	"github.com/blockcdn-go/jwt"
+)
+
+// loadSigningKey 读取签名密钥：HMAC算法使用文件的原始内容，其他算法读取PEM或JWK格式的私钥。
+// 文件中是非对称密钥时不能用作HMAC密钥，否则持有公钥的人就可以伪造令牌
+func loadSigningKey(path string, method jwt.SigningMethod) (interface{}, error) {
+	data, err := ioutil.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	if _, ok := method.(*jwt.HMACMethod); ok && !isJSON(data) {
+		if _, err := parseKey(data); err == nil {
+			return nil, fmt.Errorf("%s: %v", path, jwt.ErrInvalidKeyType)
+		}
+		return data, nil
+	}
+
+	key, err := parseKey(data)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %v", path, err)
+	}
+	return key, nil
+}
+
+// verificationKeys 表示从密钥文件中读取的验证密钥
+type verificationKeys struct {
+	// raw 是文件的原始内容，只有文件不是任何可识别的密钥格式时才作为HMAC密钥使用
+	raw       []byte
+	symmetric bool
+	key       interface{}
+	jwks      *jwt.JSONWebKeySet
+}
+
+// loadVerificationKeys 读取PEM公钥/私钥/证书、JWK、JWKS或HMAC原始密钥
+func loadVerificationKeys(path string) (*verificationKeys, error) {
+	data, err := ioutil.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	keys := &verificationKeys{raw: data}
+	if isJWKS(data) {
+		keys.jwks, err = jwt.ParseJWKS(data)
+		return keys, err
+	}
+
+	if keys.key, err = parseKey(data); err != nil {
+		if isJSON(data) {
+			return nil, err
+		}
+		keys.key = nil
+		keys.symmetric = true
+	}
+	return keys, nil
+}
+
+// methods 返回与密钥类型相符的算法
+func (k *verificationKeys) methods() []string {
+	if k.symmetric {
+		return jwt.KeyAlgorithms(k.raw)
+	}
+	if k.jwks == nil {
+		return jwt.KeyAlgorithms(k.key)
+	}
+
+	var algs []string
+	seen := make(map[string]bool)
+	for i := range k.jwks.Keys {
+		key, err := k.jwks.Keys[i].Key()
+		if err != nil {
+			continue
+		}
+		for _, alg := range jwt.KeyAlgorithms(key) {
+			if !seen[alg] {
+				seen[alg] = true
+				algs = append(algs, alg)
+			}
+		}
+	}
+	return algs
+}
+
+// KeyFunc 根据令牌的算法和kid选择验证密钥
+func (k *verificationKeys) KeyFunc(token *jwt.Token) (interface{}, error) {
+	if k.jwks != nil {
+		kid, _ := token.Header["kid"].(string)
+		for i := range k.jwks.Keys {
+			jwk := &k.jwks.Keys[i]
+			if kid != "" && jwk.KeyID != kid {
+				continue
+			}
+			key, err := jwk.Key()
+			if err == nil && jwt.KeyMatchesMethod(token.Method, key) {
+				return publicKey(key), nil
+			}
+		}
+		return nil, jwt.ErrJWKNotFound
+	}
+
+	if k.symmetric {
+		if _, ok := token.Method.(*jwt.HMACMethod); ok {
+			return k.raw, nil
+		}
+		return nil, errors.New("key file does not contain a public key")
+	}
+
+	if !jwt.KeyMatchesMethod(token.Method, k.key) {
+		return nil, jwt.ErrInvalidKeyType
+	}
+	if secret, ok := k.key.([]byte); ok {
+		return secret, nil
+	}
+	return publicKey(k.key), nil
+}
+
+// parseKey 解析PEM(PKCS1、PKCS8、SEC1、PKIX、证书或多个块)、JWK或只包含一个密钥的JWKS
+func parseKey(data []byte) (interface{}, error) {
+	if isJWKS(data) {
+		set, err := jwt.ParseJWKS(data)
+		if err != nil {
+			return nil, err
+		}
+		if len(set.Keys) != 1 {
+			return nil, fmt.Errorf("jwks contains %d keys, expected exactly one", len(set.Keys))
+		}
+		return set.Keys[0].Key()
+	}
+
+	if isJSON(data) {
+		jwk, err := jwt.ParseJWK(data)
+		if err != nil {
+			return nil, err
+		}
+		return jwk.Key()
+	}
+
+	key, _, err := jwt.ParsePrivateKeyFromPEM(data)
+	if err == jwt.ErrNoPrivateKeyInPEM {
+		key, _, err = jwt.ParsePublicKeyFromPEM(data)
+	}
+	return key, err
+}
+
+func publicKey(key interface{}) interface{} {
+	if pub, err := jwt.PublicKeyOf(key); err == nil {
+		return pub
+	}
+	return key
+}
+
+func isPrivateKey(key interface{}) bool {
+	switch key.(type) {
+	case *rsa.PrivateKey, *ecdsa.PrivateKey, ed25519.PrivateKey:
+		return true
+	}
+	return false
+}
+
+func isJSON(data []byte) bool {
+	data = bytes.TrimSpace(data)
+	return len(data) > 0 && data[0] == '{'
+}
+
+func isJWKS(data []byte) bool {
+	return isJSON(data) && bytes.Contains(data, []byte(`"keys"`))
+}
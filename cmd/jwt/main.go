@@ -0,0 +1,82 @@
+// jwt 是用于签名、验证和解码JWT Token的命令行工具
+//
+//	jwt sign -alg RS256 -key private.pem < claims.json
+//	jwt verify -key public.pem [-iss issuer] [-aud audience] [-sub subject] [token]
+//	jwt decode [token]
+//	jwt keygen -type rsa|ec|ed25519|hmac [-format pkcs8|pkcs1|sec1|jwk|jwks|raw]
+//	jwt convert -in key.pem -format pkcs8|pkcs1|sec1|pkix|jwk|jwks [-public]
+//	jwt thumbprint -in key.pem
+//
+// 退出码: 0表示成功，1表示令牌无效，2表示参数错误，3表示读取令牌或密钥失败
+package main
+
+import (
+	"fmt"
+	"io"
+	"os"
+)
+
+// 退出码
+const (
+	exitOK      = 0
+	exitInvalid = 1
+	exitUsage   = 2
+	exitError   = 3
+)
+
+const usage = `usage: jwt <command> [flags]
+
+commands:
+  sign        sign claims read from a JSON file or stdin
+  verify      verify a token against a PEM, JWK or JWKS key file
+  decode      print the header and claims of a token without verifying it
+  keygen      generate an RSA, EC, Ed25519 or HMAC key
+  convert     convert a key between PEM, JWK and JWKS forms
+  thumbprint  print the RFC 7638 JWK thumbprint of a key
+
+run "jwt <command> -h" for the flags of a command
+`
+
+func main() {
+	os.Exit(run(os.Args[1:], os.Stdin, os.Stdout, os.Stderr))
+}
+
+func run(args []string, stdin io.Reader, stdout, stderr io.Writer) int {
+	if len(args) == 0 {
+		fmt.Fprint(stderr, usage)
+		return exitUsage
+	}
+
+	cmd := &command{stdin: stdin, stdout: stdout, stderr: stderr}
+	switch args[0] {
+	case "sign":
+		return cmd.sign(args[1:])
+	case "verify":
+		return cmd.verify(args[1:])
+	case "decode":
+		return cmd.decode(args[1:])
+	case "keygen":
+		return cmd.keygen(args[1:])
+	case "convert":
+		return cmd.convert(args[1:])
+	case "thumbprint":
+		return cmd.thumbprint(args[1:])
+	case "help", "-h", "-help", "--help":
+		fmt.Fprint(stdout, usage)
+		return exitOK
+	}
+
+	fmt.Fprintf(stderr, "jwt: unknown command %q\n\n%s", args[0], usage)
+	return exitUsage
+}
+
+type command struct {
+	stdin  io.Reader
+	stdout io.Writer
+	stderr io.Writer
+}
+
+func (c *command) fail(code int, format string, a ...interface{}) int {
+	fmt.Fprintf(c.stderr, "jwt: "+format+"\n", a...)
+	return code
+}
@@ -0,0 +1,150 @@
+package main
+
+import (
+	"bytes"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/blockcdn-go/jwt"
+	"github.com/gotoxu/assert"
+)
+
+func runCommand(stdin string, args ...string) (int, string, string) {
+	var stdout, stderr bytes.Buffer
+	code := run(args, strings.NewReader(stdin), &stdout, &stderr)
+	return code, stdout.String(), stderr.String()
+}
+
+func TestSignVerifyDecode(t *testing.T) {
+	claims := fmt.Sprintf(`{"iss":"cli","aud":["api"],"sub":"alice","exp":%d}`, time.Now().Add(time.Hour).Unix())
+
+	var tests = []struct {
+		alg     string
+		signKey string
+		pubKey  string
+	}{
+		{"RS256", "../../test/sample_key", "../../test/sample_key.pub"},
+		{"PS384", "../../test/sample_key", "../../test/sample_key"},
+		{"ES256", "../../test/ec256-private.pem", "../../test/ec256-public.pem"},
+		{"HS256", "../../test/hmacTestKey", "../../test/hmacTestKey"},
+	}
+
+	for _, test := range tests {
+		code, token, stderr := runCommand(claims, "sign", "-alg", test.alg, "-key", test.signKey, "-kid", "k1")
+		assert.DeepEqual(t, code, exitOK, stderr)
+
+		code, out, stderr := runCommand(token, "verify", "-key", test.pubKey, "-iss", "cli", "-aud", "api", "-sub", "alice")
+		assert.DeepEqual(t, code, exitOK, test.alg, stderr)
+		assert.StringContains(t, out, `"alice"`)
+
+		code, _, _ = runCommand(token, "verify", "-key", test.pubKey, "-aud", "other")
+		assert.DeepEqual(t, code, exitInvalid, test.alg)
+
+		code, _, _ = runCommand(token, "verify", "-key", test.pubKey, "-alg", "HS512")
+		assert.DeepEqual(t, code, exitInvalid, test.alg)
+
+		code, out, _ = runCommand("", "decode", strings.TrimSpace(token))
+		assert.DeepEqual(t, code, exitOK, test.alg)
+		assert.StringContains(t, out, `"kid": "k1"`)
+		assert.StringContains(t, out, "exp:")
+		assert.StringContains(t, out, "claims valid")
+	}
+}
+
+func TestExitCodes(t *testing.T) {
+	code, _, _ := runCommand("")
+	assert.DeepEqual(t, code, exitUsage)
+
+	code, _, _ = runCommand("", "unknown")
+	assert.DeepEqual(t, code, exitUsage)
+
+	code, _, _ = runCommand("{}", "sign", "-alg", "XX999", "-key", "../../test/hmacTestKey")
+	assert.DeepEqual(t, code, exitUsage)
+
+	code, _, _ = runCommand("{}", "sign", "-alg", "RS256", "-key", "missing.pem")
+	assert.DeepEqual(t, code, exitError)
+
+	code, _, _ = runCommand("not-a-token", "decode")
+	assert.DeepEqual(t, code, exitInvalid)
+
+	// 已过期的令牌
+	expired := fmt.Sprintf(`{"exp":%d}`, time.Now().Add(-time.Hour).Unix())
+	_, token, _ := runCommand(expired, "sign", "-alg", "HS256", "-key", "../../test/hmacTestKey")
+	code, _, _ = runCommand(token, "verify", "-key", "../../test/hmacTestKey")
+	assert.DeepEqual(t, code, exitInvalid)
+	code, out, _ := runCommand(token, "decode")
+	assert.DeepEqual(t, code, exitInvalid)
+	assert.StringContains(t, out, "ago")
+}
+
+func TestKeyConfusion(t *testing.T) {
+	// 用公钥文件的内容作为HMAC密钥伪造的令牌
+	pub, err := ioutil.ReadFile("../../test/sample_key.pub")
+	assert.Nil(t, err)
+	forged, err := jwt.NewWithClaims(jwt.HS256Method, jwt.MapClaims{"sub": "mallory"}).Generate(pub)
+	assert.Nil(t, err)
+
+	code, _, _ := runCommand(forged, "verify", "-key", "../../test/sample_key.pub")
+	assert.DeepEqual(t, code, exitInvalid)
+
+	code, _, _ = runCommand(forged, "verify", "-key", "../../test/sample_key.pub", "-alg", "HS256")
+	assert.DeepEqual(t, code, exitInvalid)
+
+	code, _, _ = runCommand(`{"sub":"mallory"}`, "sign", "-alg", "HS256", "-key", "../../test/sample_key.pub")
+	assert.DeepEqual(t, code, exitError)
+}
+
+func TestKeygenConvertThumbprint(t *testing.T) {
+	dir, err := ioutil.TempDir("", "jwt-cli")
+	assert.Nil(t, err)
+	defer os.RemoveAll(dir)
+
+	var tests = []struct {
+		args []string
+		alg  string
+	}{
+		{[]string{"-type", "rsa", "-format", "pkcs1"}, "RS256"},
+		{[]string{"-type", "ec", "-curve", "P-384", "-format", "sec1"}, "ES384"},
+		{[]string{"-type", "ed25519"}, "EdDSA"},
+		{[]string{"-type", "ec", "-format", "jwk", "-kid", "k1"}, "ES256"},
+		{[]string{"-type", "hmac", "-size", "64"}, "HS512"},
+	}
+
+	for i, test := range tests {
+		private := filepath.Join(dir, fmt.Sprintf("key-%d", i))
+		public := private + ".pub"
+
+		code, _, stderr := runCommand("", append([]string{"keygen", "-out", private}, test.args...)...)
+		assert.DeepEqual(t, code, exitOK, test.alg, stderr)
+
+		verifyKey := private
+		if test.alg != "HS512" {
+			code, _, stderr = runCommand("", "convert", "-in", private, "-format", "jwks", "-public", "-out", public)
+			assert.DeepEqual(t, code, exitOK, test.alg, stderr)
+			verifyKey = public
+
+			code, tp1, _ := runCommand("", "thumbprint", "-in", private)
+			assert.DeepEqual(t, code, exitOK, test.alg)
+			code, tp2, _ := runCommand("", "thumbprint", "-in", public)
+			assert.DeepEqual(t, code, exitOK, test.alg)
+			assert.DeepEqual(t, tp1, tp2, test.alg)
+		}
+
+		code, token, stderr := runCommand(`{"sub":"alice"}`, "sign", "-alg", test.alg, "-key", private)
+		assert.DeepEqual(t, code, exitOK, test.alg, stderr)
+		code, _, stderr = runCommand(token, "verify", "-key", verifyKey, "-sub", "alice")
+		assert.DeepEqual(t, code, exitOK, test.alg, stderr)
+	}
+
+	code, out, _ := runCommand("", "convert", "-in", "../../test/sample_key", "-format", "pkix")
+	assert.DeepEqual(t, code, exitOK)
+	assert.StringContains(t, out, "BEGIN PUBLIC KEY")
+
+	code, _, _ = runCommand("", "convert", "-in", "../../test/sample_key.pub", "-format", "pkcs8")
+	assert.DeepEqual(t, code, exitError)
+}
@@ -0,0 +1,172 @@
+package jwt
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/gotoxu/assert"
+)
+
+type contextTestKey struct{}
+
+type contextClaims struct {
+	StandardClaims
+	ctx context.Context
+}
+
+func (c *contextClaims) ValidContext(ctx context.Context) error {
+	c.ctx = ctx
+	return c.StandardClaims.Valid()
+}
+
+func TestParseWithContext(t *testing.T) {
+	key := []byte("this-is-a-32-byte-hs256-test-key")
+	tokenString, err := NewWithClaims(HS256Method, StandardClaims{Subject: "alice"}).Generate(key)
+	assert.Nil(t, err)
+
+	ctx := context.WithValue(context.Background(), contextTestKey{}, "request")
+	var seen interface{}
+	keyFunc := func(ctx context.Context, token *Token) (interface{}, error) {
+		seen = ctx.Value(contextTestKey{})
+		return key, nil
+	}
+
+	claims := &contextClaims{}
+	token, err := ParseWithClaimsContext(ctx, tokenString, claims, keyFunc)
+	assert.Nil(t, err)
+	assert.True(t, token.Valid)
+	assert.DeepEqual(t, seen, "request")
+	assert.DeepEqual(t, claims.ctx.Value(contextTestKey{}), "request")
+
+	cancelled, cancel := context.WithCancel(ctx)
+	cancel()
+	_, err = ParseWithContext(cancelled, tokenString, func(ctx context.Context, token *Token) (interface{}, error) {
+		return nil, ctx.Err()
+	})
+	assert.NotNil(t, err)
+	assert.DeepEqual(t, err.(*ValidationError).Errors, ValidationErrorUnverifiable)
+	assert.DeepEqual(t, err.(*ValidationError).Inner, context.Canceled)
+
+	// 普通KeyFunc通过ContextKeyFunc转换
+	token, err = ParseWithContext(ctx, tokenString, ContextKeyFunc(func(*Token) (interface{}, error) { return key, nil }))
+	assert.Nil(t, err)
+	assert.True(t, token.Valid)
+}
+
+func TestRemoteKeySetContext(t *testing.T) {
+	server, jwksRequests := newTestProviderServer(t, "")
+	defer server.Close()
+
+	token := New(RS256)
+	token.Header["kid"] = "sample"
+	keys := NewRemoteKeySet(server.URL+"/jwks", server.Client())
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	_, err := keys.KeyFuncContext(ctx, token)
+	assert.NotNil(t, err)
+	assert.True(t, errors.Is(err, context.Canceled))
+
+	key, err := keys.KeyFuncContext(context.Background(), token)
+	assert.Nil(t, err)
+	assert.NotNil(t, key)
+	assert.DeepEqual(t, *jwksRequests, 1)
+
+	// 缓存过期后，已取消的ctx不会得到旧的缓存
+	defer func() { TimeFunc = time.Now }()
+	TimeFunc = func() time.Time { return time.Now().Add(2 * DefaultJWKSCacheTTL) }
+	_, err = keys.KeySetContext(ctx)
+	assert.NotNil(t, err)
+}
+
+func TestRemoteKeySetConcurrentFetch(t *testing.T) {
+	pub := loadRSAPublicKeyFromDisk("test/sample_key.pub")
+	jwk, err := NewJSONWebKey(pub)
+	assert.Nil(t, err)
+	body, err := json.Marshal(JSONWebKeySet{Keys: []JSONWebKey{*jwk}})
+	assert.Nil(t, err)
+
+	var requests int32
+	started := make(chan struct{}, 10)
+	release := make(chan struct{})
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt32(&requests, 1)
+		started <- struct{}{}
+		select {
+		case <-release:
+			w.Write(body)
+		case <-r.Context().Done():
+		}
+	}))
+	defer server.Close()
+
+	// 等待者可以在请求完成前通过自己的ctx返回
+	keys := NewRemoteKeySet(server.URL, server.Client())
+	leader, cancelLeader := context.WithCancel(context.Background())
+	leaderErr := make(chan error, 1)
+	go func() {
+		_, err := keys.KeySetContext(leader)
+		leaderErr <- err
+	}()
+	<-started
+
+	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+	defer cancel()
+	_, err = keys.KeySetContext(ctx)
+	assert.DeepEqual(t, err, context.DeadlineExceeded)
+
+	var wg sync.WaitGroup
+	results := make(chan error, 5)
+	for i := 0; i < 5; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			set, err := keys.KeySetContext(context.Background())
+			if err == nil && len(set.Keys) != 1 {
+				err = errors.New("unexpected key set")
+			}
+			results <- err
+		}()
+	}
+
+	// 发起请求的调用者取消后，仍在等待的调用者重新请求
+	cancelLeader()
+	assert.True(t, errors.Is(<-leaderErr, context.Canceled))
+	<-started
+	close(release)
+	wg.Wait()
+	close(results)
+	for err := range results {
+		assert.Nil(t, err)
+	}
+	assert.DeepEqual(t, atomic.LoadInt32(&requests), int32(2))
+}
+
+type contextReplayCache struct {
+	err error
+}
+
+func (c *contextReplayCache) Seen(id string, expiresAt time.Time) bool {
+	return false
+}
+
+func (c *contextReplayCache) SeenContext(ctx context.Context, id string, expiresAt time.Time) (bool, error) {
+	return false, c.err
+}
+
+func TestReplaySeenContext(t *testing.T) {
+	exp := time.Now().Add(time.Minute)
+	assert.False(t, replaySeen(context.Background(), &contextReplayCache{}, "a", exp))
+	assert.True(t, replaySeen(context.Background(), &contextReplayCache{err: errors.New("unavailable")}, "a", exp))
+
+	cache := NewMemoryReplayCache()
+	assert.False(t, replaySeen(context.Background(), cache, "a", exp))
+	assert.True(t, replaySeen(context.Background(), cache, "a", exp))
+}
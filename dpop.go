@@ -0,0 +1,256 @@
+package jwt
+
+import (
+	"context"
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/json"
+	"errors"
+	"net/url"
+	"strings"
+	"time"
+)
+
+// errors
+var (
+	ErrDPoPType         = errors.New("dpop: proof typ must be dpop+jwt")
+	ErrDPoPKey          = errors.New("dpop: proof must carry a public jwk header")
+	ErrDPoPMethod       = errors.New("dpop: htm does not match the request method")
+	ErrDPoPURI          = errors.New("dpop: htu does not match the request uri")
+	ErrDPoPIssuedAt     = errors.New("dpop: iat is outside the acceptable window")
+	ErrDPoPReplay       = errors.New("dpop: proof has already been used")
+	ErrDPoPAccessToken  = errors.New("dpop: ath does not match the access token")
+	ErrDPoPBinding      = errors.New("dpop: proof key does not match the access token cnf.jkt")
+	ErrDPoPMissingClaim = errors.New("dpop: proof is missing jti, htm, htu or iat")
+)
+
+// DPoPProofType 是RFC 9449规定的DPoP proof的typ头部
+const DPoPProofType = "dpop+jwt"
+
+// DefaultDPoPMaxAge 是DPoP proof的默认最大有效时长
+const DefaultDPoPMaxAge = 5 * time.Minute
+
+// dpopMethods 是DPoP proof默认允许的非对称签名算法
+var dpopMethods = []string{"RS256", "RS384", "RS512", "PS256", "PS384", "PS512", "ES256", "ES384", "ES512", "EdDSA"}
+
+// DPoPClaims 是DPoP proof的claims
+type DPoPClaims struct {
+	ID              string `json:"jti"`
+	HTTPMethod      string `json:"htm"`
+	HTTPURI         string `json:"htu"`
+	IssuedAt        int64  `json:"iat"`
+	AccessTokenHash string `json:"ath,omitempty"`
+	Nonce           string `json:"nonce,omitempty"`
+}
+
+// Valid 实现Claims接口，时间窗口由DPoPVerifier检查
+func (c *DPoPClaims) Valid() error {
+	return nil
+}
+
+// Confirmation 是RFC 7800定义的cnf claim
+type Confirmation struct {
+	JWKThumbprint string `json:"jkt,omitempty"`
+	X5TS256       string `json:"x5t#S256,omitempty"`
+}
+
+// DPoPNonceError 表示服务端要求客户端使用新的nonce重新生成proof，
+// 对应RFC 9449中的use_dpop_nonce错误，Nonce应通过DPoP-Nonce头部返回给客户端
+type DPoPNonceError struct {
+	Nonce string
+}
+
+func (e *DPoPNonceError) Error() string {
+	return "use_dpop_nonce"
+}
+
+// DPoPAccessTokenHash 计算ath claim的值
+func DPoPAccessTokenHash(accessToken string) string {
+	sum := sha256.Sum256([]byte(accessToken))
+	return EncodeSegment(sum[:])
+}
+
+// NewDPoPProof 使用私钥key创建一个DPoP proof，jwk头部中嵌入key对应的公钥。
+// accessToken不为空时设置ath，nonce不为空时设置nonce
+func NewDPoPProof(method SigningMethod, key interface{}, htm, htu, accessToken, nonce string) (string, error) {
+	jwk, err := NewJSONWebKey(key)
+	if err != nil {
+		return "", err
+	}
+	if jwk = jwk.Public(); jwk == nil {
+		return "", ErrDPoPKey
+	}
+
+	jti, err := newTokenID()
+	if err != nil {
+		return "", err
+	}
+
+	claims := &DPoPClaims{
+		ID:         jti,
+		HTTPMethod: htm,
+		HTTPURI:    htu,
+		IssuedAt:   TimeFunc().Unix(),
+		Nonce:      nonce,
+	}
+	if accessToken != "" {
+		claims.AccessTokenHash = DPoPAccessTokenHash(accessToken)
+	}
+
+	token := NewWithClaims(method, claims)
+	token.Header["typ"] = DPoPProofType
+	token.Header["jwk"] = jwk
+	return token.Generate(key)
+}
+
+// DPoPProof 是验证通过的DPoP proof
+type DPoPProof struct {
+	Token  *Token
+	Claims *DPoPClaims
+	// Key 是proof中携带的公钥
+	Key interface{}
+	// Thumbprint 是公钥的JWK SHA-256指纹，用于与cnf.jkt比较
+	Thumbprint string
+}
+
+// DPoPVerifier 按照RFC 9449验证DPoP proof
+type DPoPVerifier struct {
+	// ValidMethods 是允许的签名算法，为空时使用dpopMethods，即RS*、PS*、ES*和EdDSA
+	ValidMethods []string
+	// MaxAge 是iat距今的最大时长，为0时使用DefaultDPoPMaxAge
+	MaxAge time.Duration
+	// Leeway 是允许iat超前于当前时间的时钟偏差
+	Leeway time.Duration
+	// Replay 不为空时用于拒绝重复使用的proof
+	Replay ReplayCache
+	// ValidNonce 不为空时proof必须携带nonce，且ValidNonce返回true
+	ValidNonce func(nonce string) bool
+	// NewNonce 生成返回给客户端的新nonce，用于构造DPoPNonceError
+	NewNonce func() string
+}
+
+// Verify 验证针对一次HTTP请求的DPoP proof。
+// accessToken不为空时要求ath与之匹配
+func (v *DPoPVerifier) Verify(proof, htm, htu, accessToken string) (*DPoPProof, error) {
+	return v.VerifyContext(context.Background(), proof, htm, htu, accessToken)
+}
+
+// VerifyContext 与Verify相同，ctx会传递给Replay
+func (v *DPoPVerifier) VerifyContext(ctx context.Context, proof, htm, htu, accessToken string) (*DPoPProof, error) {
+	methods := v.ValidMethods
+	if len(methods) == 0 {
+		methods = dpopMethods
+	}
+
+	result := new(DPoPProof)
+	p := &Parser{ValidMethods: methods}
+	token, err := p.ParseWithClaimsContext(ctx, proof, &DPoPClaims{}, func(_ context.Context, token *Token) (interface{}, error) {
+		if typ, _ := token.Header["typ"].(string); !strings.EqualFold(typ, DPoPProofType) {
+			return nil, &ValidationError{Inner: ErrDPoPType, Errors: ValidationErrorMalformed}
+		}
+
+		jwk, err := headerJWK(token)
+		if err != nil {
+			return nil, err
+		}
+		if result.Thumbprint, err = jwk.Thumbprint(); err != nil {
+			return nil, err
+		}
+		result.Key, err = jwk.Key()
+		return result.Key, err
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	claims := token.Claims.(*DPoPClaims)
+	result.Token = token
+	result.Claims = claims
+
+	if claims.ID == "" || claims.HTTPMethod == "" || claims.HTTPURI == "" || claims.IssuedAt == 0 {
+		return nil, &ValidationError{Inner: ErrDPoPMissingClaim, Errors: ValidationErrorClaimsInvalid}
+	}
+
+	if claims.HTTPMethod != htm {
+		return nil, &ValidationError{Inner: ErrDPoPMethod, Errors: ValidationErrorClaimsInvalid}
+	}
+
+	if !dpopURIMatches(claims.HTTPURI, htu) {
+		return nil, &ValidationError{Inner: ErrDPoPURI, Errors: ValidationErrorClaimsInvalid}
+	}
+
+	maxAge := v.MaxAge
+	if maxAge <= 0 {
+		maxAge = DefaultDPoPMaxAge
+	}
+	now := TimeFunc()
+	iat := time.Unix(claims.IssuedAt, 0)
+	if iat.After(now.Add(v.Leeway)) || iat.Before(now.Add(-maxAge)) {
+		return nil, &ValidationError{Inner: ErrDPoPIssuedAt, Errors: ValidationErrorIssuedAt}
+	}
+
+	if accessToken != "" {
+		expected := DPoPAccessTokenHash(accessToken)
+		if subtle.ConstantTimeCompare([]byte(claims.AccessTokenHash), []byte(expected)) == 0 {
+			return nil, &ValidationError{Inner: ErrDPoPAccessToken, Errors: ValidationErrorClaimsInvalid}
+		}
+	}
+
+	if v.ValidNonce != nil && (claims.Nonce == "" || !v.ValidNonce(claims.Nonce)) {
+		nonceErr := &DPoPNonceError{}
+		if v.NewNonce != nil {
+			nonceErr.Nonce = v.NewNonce()
+		}
+		return nil, &ValidationError{Inner: nonceErr, Errors: ValidationErrorClaimsInvalid}
+	}
+
+	if v.Replay != nil && replaySeen(ctx, v.Replay, result.Thumbprint+":"+claims.ID, iat.Add(maxAge+v.Leeway)) {
+		return nil, &ValidationError{Inner: ErrDPoPReplay, Errors: ValidationErrorID}
+	}
+
+	return result, nil
+}
+
+// VerifyBinding 验证proof的公钥与访问令牌cnf.jkt中绑定的密钥一致
+func (p *DPoPProof) VerifyBinding(cnf *Confirmation) error {
+	if cnf == nil || cnf.JWKThumbprint == "" ||
+		subtle.ConstantTimeCompare([]byte(cnf.JWKThumbprint), []byte(p.Thumbprint)) == 0 {
+		return &ValidationError{Inner: ErrDPoPBinding, Errors: ValidationErrorClaimsInvalid}
+	}
+	return nil
+}
+
+// headerJWK 读取令牌jwk头部中的公钥，拒绝对称密钥和私钥
+func headerJWK(token *Token) (*JSONWebKey, error) {
+	raw, ok := token.Header["jwk"]
+	if !ok {
+		return nil, &ValidationError{Inner: ErrDPoPKey, Errors: ValidationErrorUnverifiable}
+	}
+
+	data, err := json.Marshal(raw)
+	if err != nil {
+		return nil, &ValidationError{Inner: ErrDPoPKey, Errors: ValidationErrorUnverifiable}
+	}
+
+	jwk, err := ParseJWK(data)
+	if err != nil || jwk.KeyType == "oct" || jwk.IsPrivate() {
+		return nil, &ValidationError{Inner: ErrDPoPKey, Errors: ValidationErrorUnverifiable}
+	}
+	return jwk, nil
+}
+
+// dpopURIMatches 比较htu与请求URI，忽略query和fragment，scheme和host不区分大小写
+func dpopURIMatches(htu, requestURI string) bool {
+	a, err := url.Parse(htu)
+	if err != nil {
+		return false
+	}
+	b, err := url.Parse(requestURI)
+	if err != nil {
+		return false
+	}
+
+	return strings.EqualFold(a.Scheme, b.Scheme) &&
+		strings.EqualFold(a.Host, b.Host) &&
+		a.EscapedPath() == b.EscapedPath()
+}
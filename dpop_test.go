@@ -0,0 +1,88 @@
+package jwt
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"testing"
+	"time"
+
+	"github.com/gotoxu/assert"
+)
+
+func TestJWKThumbprint(t *testing.T) {
+	// RFC 7638 3.1节中的示例
+	jwk := &JSONWebKey{
+		KeyType: "RSA",
+		N:       "0vx7agoebGcQSuuPiLJXZptN9nndrQmbXEps2aiAFbWhM78LhWx4cbbfAAtVT86zwu1RK7aPFFxuhDR1L6tSoc_BJECPebWKRXjBZCiFV4n3oknjhMstn64tZ_2W-5JsGY4Hc5n9yBXArwl93lqt7_RN5w6Cf0h4QyQ5v-65YGjQR0_FDW2QvzqY368QQMicAtaSqzs8KJZgnYb9c7d0zgdAZHzu6qMQvRL5hajrn1n91CbOpbISD08qNLyrdkt-bFTWhAI4vMQFh6WeZu0fM4lFd2NcRwr3XPksINHaQ-G_xBniIqbw0Ls1jF44-csFCur-kEgU8awapJzKnqDKgw",
+		E:       "AQAB",
+	}
+	thumbprint, err := jwk.Thumbprint()
+	assert.Nil(t, err)
+	assert.DeepEqual(t, thumbprint, "NzbLsXh8uDCcd-6MNwXF4W_7noWXFZAfHkxZsRGC9Xs")
+}
+
+func TestDPoP(t *testing.T) {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	assert.Nil(t, err)
+
+	jwk, _ := NewJSONWebKey(&key.PublicKey)
+	jkt, _ := jwk.Thumbprint()
+
+	proof, err := NewDPoPProof(ES256, key, "GET", "https://api.example.com/resource?x=1", "access-token", "")
+	assert.Nil(t, err)
+
+	v := &DPoPVerifier{Replay: NewMemoryReplayCache()}
+	result, err := v.Verify(proof, "GET", "https://API.example.com/resource", "access-token")
+	assert.Nil(t, err)
+	assert.DeepEqual(t, result.Thumbprint, jkt)
+	assert.Nil(t, result.VerifyBinding(&Confirmation{JWKThumbprint: jkt}))
+	assert.NotNil(t, result.VerifyBinding(&Confirmation{JWKThumbprint: "other"}))
+
+	// 重放
+	_, err = v.Verify(proof, "GET", "https://api.example.com/resource", "access-token")
+	assert.NotNil(t, err)
+
+	proof, _ = NewDPoPProof(ES256, key, "GET", "https://api.example.com/resource", "access-token", "")
+	_, err = v.Verify(proof, "POST", "https://api.example.com/resource", "access-token")
+	assert.NotNil(t, err)
+	_, err = v.Verify(proof, "GET", "https://api.example.com/other", "access-token")
+	assert.NotNil(t, err)
+	_, err = v.Verify(proof, "GET", "https://api.example.com/resource", "other-token")
+	assert.NotNil(t, err)
+
+	// 过期的proof
+	TimeFunc = func() time.Time { return time.Now().Add(-time.Hour) }
+	proof, _ = NewDPoPProof(ES256, key, "GET", "https://api.example.com/resource", "", "")
+	TimeFunc = time.Now
+	_, err = v.Verify(proof, "GET", "https://api.example.com/resource", "")
+	assert.NotNil(t, err)
+
+	// 对称算法不能用于DPoP
+	token := NewWithClaims(HS256Method, &DPoPClaims{ID: "1", HTTPMethod: "GET", HTTPURI: "https://api.example.com/", IssuedAt: time.Now().Unix()})
+	token.Header["typ"] = DPoPProofType
+	token.Header["jwk"] = &JSONWebKey{KeyType: "oct", K: EncodeSegment(hmacTestKey)}
+	proof, _ = token.Generate(hmacTestKey)
+	_, err = v.Verify(proof, "GET", "https://api.example.com/", "")
+	assert.NotNil(t, err)
+}
+
+func TestDPoPNonce(t *testing.T) {
+	key, _ := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+
+	v := &DPoPVerifier{
+		ValidNonce: func(nonce string) bool { return nonce == "server-nonce" },
+		NewNonce:   func() string { return "server-nonce" },
+	}
+
+	proof, _ := NewDPoPProof(ES256, key, "POST", "https://as.example.com/token", "", "")
+	_, err := v.Verify(proof, "POST", "https://as.example.com/token", "")
+	assert.NotNil(t, err)
+
+	nonceErr, ok := err.(*ValidationError).Inner.(*DPoPNonceError)
+	assert.True(t, ok)
+
+	proof, _ = NewDPoPProof(ES256, key, "POST", "https://as.example.com/token", "", nonceErr.Nonce)
+	_, err = v.Verify(proof, "POST", "https://as.example.com/token", "")
+	assert.Nil(t, err)
+}
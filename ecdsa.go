@@ -46,6 +46,10 @@ func (m *ECDSAMethod) Algorithm() string {
 
 // Verify 验证签名
 func (m *ECDSAMethod) Verify(signingString, signature string, key interface{}) error {
+	return m.verifyWithPolicy(signingString, signature, key, defaultKeyPolicy)
+}
+
+func (m *ECDSAMethod) verifyWithPolicy(signingString, signature string, key interface{}, policy *KeyPolicy) error {
 	var err error
 
 	var sig []byte
@@ -61,6 +65,10 @@ func (m *ECDSAMethod) Verify(signingString, signature string, key interface{}) e
 		return ErrInvalidKeyType
 	}
 
+	if err = policy.Check(m, ecdsaKey); err != nil {
+		return err
+	}
+
 	if len(sig) != 2*m.KeySize {
 		return ErrECDSAVerification
 	}
@@ -92,6 +100,10 @@ func (m *ECDSAMethod) Sign(signingString string, key interface{}) (string, error
 		return "", ErrInvalidKeyType
 	}
 
+	if err := defaultKeyPolicy.Check(m, ecdsaKey); err != nil {
+		return "", err
+	}
+
 	if !m.Hash.Available() {
 		return "", ErrHashUnavailable
 	}
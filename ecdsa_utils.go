@@ -13,7 +13,7 @@ var (
 	ErrNotECPrivateKey = errors.New("Key is not a valid ECDSA private key")
 )
 
-// ParseECPrivateKeyFromPEM 解析PEM编码的ECDSA私钥
+// ParseECPrivateKeyFromPEM 解析PEM编码的SEC1或PKCS8格式的ECDSA私钥
 func ParseECPrivateKeyFromPEM(key []byte) (*ecdsa.PrivateKey, error) {
 	var err error
 
@@ -24,6 +24,36 @@ func ParseECPrivateKeyFromPEM(key []byte) (*ecdsa.PrivateKey, error) {
 
 	var parsedKey interface{}
 	if parsedKey, err = x509.ParseECPrivateKey(block.Bytes); err != nil {
+		if parsedKey, err = x509.ParsePKCS8PrivateKey(block.Bytes); err != nil {
+			return nil, err
+		}
+	}
+
+	var pkey *ecdsa.PrivateKey
+	var ok bool
+	if pkey, ok = parsedKey.(*ecdsa.PrivateKey); !ok {
+		return nil, ErrNotECPrivateKey
+	}
+
+	return pkey, nil
+}
+
+// ParseECPrivateKeyFromPEMWithPassword 将字节数组转换为ECDSA私钥，支持PBES2加密的PKCS8。
+// 传统的PEM加密返回ErrLegacyPEMEncryption，需要显式调用ParseECPrivateKeyFromLegacyPEM
+func ParseECPrivateKeyFromPEMWithPassword(key []byte, password string) (*ecdsa.PrivateKey, error) {
+	var err error
+
+	var block *pem.Block
+	if block, _ = pem.Decode(key); block == nil {
+		return nil, ErrKeyMustBePEMEncoded
+	}
+
+	if block.Type != "ENCRYPTED PRIVATE KEY" {
+		return nil, ErrLegacyPEMEncryption
+	}
+
+	var parsedKey interface{}
+	if parsedKey, err = DecryptPKCS8PrivateKey(block.Bytes, []byte(password)); err != nil {
 		return nil, err
 	}
 
@@ -62,3 +92,25 @@ func ParseECPublicKeyFromPEM(key []byte) (*ecdsa.PublicKey, error) {
 
 	return pkey, nil
 }
+
+// ParseECPrivateKeyFromLegacyPEM 解密RFC 1423格式(Proc-Type: 4,ENCRYPTED)的PEM块并转换为ECDSA私钥。
+// 这种格式不安全，只应用于迁移旧的密钥文件
+func ParseECPrivateKeyFromLegacyPEM(key []byte, password string) (*ecdsa.PrivateKey, error) {
+	decrypted, err := decryptLegacyPEM(key, password)
+	if err != nil {
+		return nil, err
+	}
+
+	var parsedKey interface{}
+	if parsedKey, err = x509.ParseECPrivateKey(decrypted); err != nil {
+		if parsedKey, err = x509.ParsePKCS8PrivateKey(decrypted); err != nil {
+			return nil, err
+		}
+	}
+
+	pkey, ok := parsedKey.(*ecdsa.PrivateKey)
+	if !ok {
+		return nil, ErrNotECPrivateKey
+	}
+	return pkey, nil
+}
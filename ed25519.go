@@ -0,0 +1,75 @@
+package jwt
+
+import (
+	"crypto/ed25519"
+	"errors"
+)
+
+// errors
+var (
+	ErrEd25519Verification = errors.New("crypto/ed25519: verification error")
+)
+
+// EdDSAMethod 实现基于Ed25519的EdDSA签名方法(RFC 8037)
+type EdDSAMethod struct {
+	Name string
+}
+
+// EdDSA method
+var (
+	EdDSA *EdDSAMethod
+)
+
+func init() {
+	EdDSA = &EdDSAMethod{"EdDSA"}
+	RegisterSigningMethod(EdDSA.Algorithm(), EdDSA)
+}
+
+// Algorithm 返回算法名称字符串
+func (m *EdDSAMethod) Algorithm() string {
+	return m.Name
+}
+
+// Verify 验证签名
+func (m *EdDSAMethod) Verify(signingString, signature string, key interface{}) error {
+	var err error
+
+	var sig []byte
+	if sig, err = DecodeSegment(signature); err != nil {
+		return err
+	}
+
+	var edKey ed25519.PublicKey
+	switch k := key.(type) {
+	case ed25519.PublicKey:
+		edKey = k
+	default:
+		return ErrInvalidKeyType
+	}
+
+	if len(edKey) != ed25519.PublicKeySize {
+		return ErrInvalidKey
+	}
+
+	if !ed25519.Verify(edKey, []byte(signingString), sig) {
+		return ErrEd25519Verification
+	}
+	return nil
+}
+
+// Sign 计算签名
+func (m *EdDSAMethod) Sign(signingString string, key interface{}) (string, error) {
+	var edKey ed25519.PrivateKey
+	switch k := key.(type) {
+	case ed25519.PrivateKey:
+		edKey = k
+	default:
+		return "", ErrInvalidKeyType
+	}
+
+	if len(edKey) != ed25519.PrivateKeySize {
+		return "", ErrInvalidKey
+	}
+
+	return EncodeSegment(ed25519.Sign(edKey, []byte(signingString))), nil
+}
@@ -0,0 +1,44 @@
+package jwt
+
+import (
+	"crypto/ed25519"
+	"strings"
+	"testing"
+
+	"github.com/gotoxu/assert"
+)
+
+func TestEdDSA(t *testing.T) {
+	// RFC 8037 附录A.4中的示例
+	jwk := &JSONWebKey{
+		KeyType: "OKP",
+		Curve:   "Ed25519",
+		D:       "nWGxne_9WmC6hEr0kuwsxERJxWl7MmkZcDusAxyuf2A",
+		X:       "11qYAYKxCrfVS_7TyWQHOg7hcvPapiMlrwIaaPcHURo",
+	}
+	key, err := jwk.Key()
+	assert.Nil(t, err)
+	privateKey := key.(ed25519.PrivateKey)
+
+	signingString := "eyJhbGciOiJFZERTQSJ9.RXhhbXBsZSBvZiBFZDI1NTE5IHNpZ25pbmc"
+	sig, err := EdDSA.Sign(signingString, privateKey)
+	assert.Nil(t, err)
+	assert.DeepEqual(t, sig, "hgyY0il_MGCjP0JzlnLWG1PPOt7-09PGcvMg3AIbQR6dWbhijcNR4ki4iylGjg5BhVsPt9g7sVvpAr_MuM0KAg")
+
+	publicKey := privateKey.Public()
+	assert.Nil(t, EdDSA.Verify(signingString, sig, publicKey))
+	assert.NotNil(t, EdDSA.Verify(strings.Replace(signingString, "R", "S", 1), sig, publicKey))
+	assert.NotNil(t, EdDSA.Verify(signingString, sig, privateKey))
+
+	thumbprint, err := jwk.Public().Thumbprint()
+	assert.Nil(t, err)
+	assert.DeepEqual(t, thumbprint, "kPrK_qmxVWaYVA9wwBF6Iuo3vVzz7TxHCTwXBygrS4k")
+}
+
+func BenchmarkEdDSASigning(b *testing.B) {
+	key, err := GenerateEd25519Key()
+	if err != nil {
+		b.Fatal(err)
+	}
+	benchmarkSigning(b, EdDSA, key)
+}
@@ -22,6 +22,9 @@ const (
 	ValidationErrorNotValidYet
 	ValidationErrorID
 	ValidationErrorClaimsInvalid
+	ValidationErrorHeaderType
+	ValidationErrorContentType
+	ValidationErrorCriticalHeader
 )
 
 // NewValidationError 使用给定的错误消息创建一个ValidationError对象
@@ -0,0 +1,244 @@
+package jwt
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// DefaultFileKeyPollInterval 是FileKeyProvider默认的轮询间隔
+const DefaultFileKeyPollInterval = 10 * time.Second
+
+// FileKeyProvider 从目录中加载PEM、JWK和JWKS文件作为验证密钥，并通过轮询在文件变化时重新加载。
+// JWKS中的密钥按kid索引，PEM文件以及没有kid的JWK使用去掉扩展名的文件名作为kid。
+// 新的密钥集完整加载成功后才会原子地替换旧的密钥集，加载失败时保留旧的密钥集。
+// 直接构造时需要先设置字段，再调用Reload和Start
+type FileKeyProvider struct {
+	Dir string
+	// Interval 是轮询间隔，为0时使用DefaultFileKeyPollInterval，只在Start时读取
+	Interval time.Duration
+	// OnError 在后台重新加载失败时被调用，可以为nil
+	OnError func(error)
+
+	keys   atomic.Value // *fileKeySet
+	mu     sync.Mutex
+	stop   chan struct{}
+	stopWG sync.WaitGroup
+}
+
+type fileKeySet struct {
+	set    *JSONWebKeySet
+	digest [sha256.Size]byte
+}
+
+// NewFileKeyProvider 加载dir中的密钥并以interval为间隔开始轮询，首次加载失败时返回错误。
+// interval为0时使用DefaultFileKeyPollInterval
+func NewFileKeyProvider(dir string, interval time.Duration, onError func(error)) (*FileKeyProvider, error) {
+	p := &FileKeyProvider{Dir: dir, Interval: interval, OnError: onError}
+	if err := p.Reload(); err != nil {
+		return nil, err
+	}
+	p.Start()
+	return p, nil
+}
+
+// Start 开始后台轮询，重复调用无效
+func (p *FileKeyProvider) Start() {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	if p.stop != nil {
+		return
+	}
+	p.stop = make(chan struct{})
+
+	interval := p.Interval
+	if interval <= 0 {
+		interval = DefaultFileKeyPollInterval
+	}
+
+	p.stopWG.Add(1)
+	go func(stop chan struct{}) {
+		defer p.stopWG.Done()
+
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-stop:
+				return
+			case <-ticker.C:
+				if err := p.Reload(); err != nil && p.OnError != nil {
+					p.OnError(err)
+				}
+			}
+		}
+	}(p.stop)
+}
+
+// Close 停止后台轮询
+func (p *FileKeyProvider) Close() error {
+	p.mu.Lock()
+	if p.stop != nil {
+		close(p.stop)
+		p.stop = nil
+	}
+	p.mu.Unlock()
+
+	p.stopWG.Wait()
+	return nil
+}
+
+// Reload 立即重新读取目录，文件内容没有变化时不会替换密钥集
+func (p *FileKeyProvider) Reload() error {
+	set, digest, err := loadKeyDir(p.Dir)
+	if err != nil {
+		return err
+	}
+
+	if current, ok := p.keys.Load().(*fileKeySet); ok && current.digest == digest {
+		return nil
+	}
+	p.keys.Store(&fileKeySet{set: set, digest: digest})
+	return nil
+}
+
+// KeyFunc 根据kid返回验证密钥。令牌没有kid时返回与签名算法匹配的密钥，
+// 有多个匹配的密钥时返回包含它们的VerificationKeySet，由Parser逐个尝试
+func (p *FileKeyProvider) KeyFunc(token *Token) (interface{}, error) {
+	current, ok := p.keys.Load().(*fileKeySet)
+	if !ok {
+		return nil, ErrJWKNotFound
+	}
+	return current.set.selectKey(token)
+}
+
+// JWKS 返回当前加载的非对称密钥的公钥部分，使FileKeyProvider可以作为KeySource
+func (p *FileKeyProvider) JWKS() (*JSONWebKeySet, error) {
+	set := &JSONWebKeySet{Keys: []JSONWebKey{}}
+	if current, ok := p.keys.Load().(*fileKeySet); ok {
+		for i := range current.set.Keys {
+			if key := current.set.Keys[i].Public(); key != nil {
+				set.Keys = append(set.Keys, *key)
+			}
+		}
+	}
+	return set, nil
+}
+
+// loadKeyDir 读取目录中的所有密钥文件。
+// 以"."开头的文件被忽略，这也跳过了Kubernetes挂载secret时使用的"..data"等目录
+func loadKeyDir(dir string) (*JSONWebKeySet, [sha256.Size]byte, error) {
+	var digest [sha256.Size]byte
+
+	entries, err := ioutil.ReadDir(dir)
+	if err != nil {
+		return nil, digest, err
+	}
+
+	var names []string
+	for _, entry := range entries {
+		if !strings.HasPrefix(entry.Name(), ".") {
+			names = append(names, entry.Name())
+		}
+	}
+	sort.Strings(names)
+
+	h := sha256.New()
+	set := &JSONWebKeySet{}
+	seen := make(map[string]string)
+	for _, name := range names {
+		path := filepath.Join(dir, name)
+		info, err := os.Stat(path)
+		if err != nil {
+			return nil, digest, err
+		}
+		if info.IsDir() {
+			continue
+		}
+
+		data, err := ioutil.ReadFile(path)
+		if err != nil {
+			return nil, digest, err
+		}
+		fmt.Fprintf(h, "%s\x00%d\x00", name, len(data))
+		h.Write(data)
+
+		keys, err := parseKeyFile(name, data)
+		if err != nil {
+			return nil, digest, fmt.Errorf("%s: %v", path, err)
+		}
+		for _, key := range keys {
+			if other, ok := seen[key.KeyID]; ok {
+				return nil, digest, fmt.Errorf("%s: kid %q is already used by %s", path, key.KeyID, other)
+			}
+			seen[key.KeyID] = name
+			set.Keys = append(set.Keys, key)
+		}
+	}
+
+	copy(digest[:], h.Sum(nil))
+	return set, digest, nil
+}
+
+// parseKeyFile 将一个PEM、JWK或JWKS文件转换为只包含验证材料的JWK
+func parseKeyFile(name string, data []byte) ([]JSONWebKey, error) {
+	defaultKID := strings.TrimSuffix(name, filepath.Ext(name))
+	trimmed := bytes.TrimSpace(data)
+
+	var keys []JSONWebKey
+	switch {
+	case bytes.HasPrefix(trimmed, []byte("{")) && bytes.Contains(trimmed, []byte(`"keys"`)):
+		set, err := ParseJWKS(trimmed)
+		if err != nil {
+			return nil, err
+		}
+		for _, key := range set.Keys {
+			if key.KeyID == "" {
+				return nil, fmt.Errorf("jwks keys must have a kid")
+			}
+			keys = append(keys, key)
+		}
+	case bytes.HasPrefix(trimmed, []byte("{")):
+		key, err := ParseJWK(trimmed)
+		if err != nil {
+			return nil, err
+		}
+		if key.KeyID == "" {
+			key.KeyID = defaultKID
+		}
+		keys = append(keys, *key)
+	default:
+		key, _, err := ParsePrivateKeyFromPEM(data)
+		if err == ErrNoPrivateKeyInPEM {
+			key, _, err = ParsePublicKeyFromPEM(data)
+		}
+		if err != nil {
+			return nil, err
+		}
+		jwk, err := NewJSONWebKey(key)
+		if err != nil {
+			return nil, err
+		}
+		jwk.KeyID = defaultKID
+		keys = append(keys, *jwk)
+	}
+
+	for i := range keys {
+		if _, err := keys[i].Key(); err != nil {
+			return nil, err
+		}
+		if pub := keys[i].Public(); pub != nil {
+			keys[i] = *pub
+		}
+	}
+	return keys, nil
+}
@@ -0,0 +1,161 @@
+package jwt
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/gotoxu/assert"
+)
+
+func TestFileKeyProvider(t *testing.T) {
+	dir, err := ioutil.TempDir("", "jwt-keys")
+	assert.Nil(t, err)
+	defer os.RemoveAll(dir)
+
+	rsaKey := loadRSAPrivateKeyFromDisk("test/sample_key")
+	ecKey, _ := GenerateKeyForMethod(ES256)
+	secret, _ := GenerateHMACSecret(32)
+
+	pub, _ := ioutil.ReadFile("test/sample_key.pub")
+	assert.Nil(t, ioutil.WriteFile(filepath.Join(dir, "rsa.pem"), pub, 0600))
+	ecPEM, _ := MarshalPrivateKeyToPEM(ecKey, PKCS8)
+	assert.Nil(t, ioutil.WriteFile(filepath.Join(dir, "ec.key"), ecPEM, 0600))
+	oct, _ := NewJSONWebKey(secret)
+	oct.KeyID = "shared"
+	octJSON, _ := json.Marshal(JSONWebKeySet{Keys: []JSONWebKey{*oct}})
+	assert.Nil(t, ioutil.WriteFile(filepath.Join(dir, "hmac.json"), octJSON, 0600))
+	assert.Nil(t, ioutil.WriteFile(filepath.Join(dir, ".hidden"), []byte("not a key"), 0600))
+
+	var mu sync.Mutex
+	var errs []error
+	provider, err := NewFileKeyProvider(dir, 10*time.Millisecond, func(err error) {
+		mu.Lock()
+		errs = append(errs, err)
+		mu.Unlock()
+	})
+	assert.Nil(t, err)
+	defer provider.Close()
+
+	sign := func(method SigningMethod, kid string, key interface{}) string {
+		token := New(method)
+		token.Header["kid"] = kid
+		s, err := token.Generate(key)
+		assert.Nil(t, err)
+		return s
+	}
+
+	var tests = []struct {
+		name  string
+		token string
+		valid bool
+	}{
+		{"rsa by filename", sign(RS256, "rsa", rsaKey), true},
+		{"ec by filename", sign(ES256, "ec", ecKey), true},
+		{"hmac by kid", sign(HS256Method, "shared", secret), true},
+		{"unknown kid", sign(ES256, "missing", ecKey), false},
+		{"wrong kid", sign(ES256, "rsa", ecKey), false},
+	}
+	for _, test := range tests {
+		_, err := Parse(test.token, provider.KeyFunc)
+		if test.valid {
+			assert.Nil(t, err, test.name)
+		} else {
+			assert.NotNil(t, err, test.name)
+		}
+	}
+
+	jwks, _ := provider.JWKS()
+	assert.DeepEqual(t, len(jwks.Keys), 2)
+	for _, k := range jwks.Keys {
+		assert.False(t, k.IsPrivate())
+	}
+
+	// 替换文件后轮询会加载新的密钥
+	newKey, _ := GenerateKeyForMethod(ES256)
+	newPEM, _ := MarshalPublicKeyToPEM(newKey, PKIX)
+	assert.Nil(t, ioutil.WriteFile(filepath.Join(dir, "ec.key"), newPEM, 0600))
+	waitFor(t, func() bool {
+		_, err := Parse(sign(ES256, "ec", newKey), provider.KeyFunc)
+		return err == nil
+	})
+	_, err = Parse(sign(ES256, "ec", ecKey), provider.KeyFunc)
+	assert.NotNil(t, err)
+
+	// 加载失败时保留旧的密钥集并报告错误
+	assert.Nil(t, ioutil.WriteFile(filepath.Join(dir, "broken.pem"), []byte("garbage"), 0600))
+	waitFor(t, func() bool {
+		mu.Lock()
+		defer mu.Unlock()
+		return len(errs) > 0
+	})
+	_, err = Parse(sign(ES256, "ec", newKey), provider.KeyFunc)
+	assert.Nil(t, err)
+}
+
+func TestFileKeyProviderWithoutKeyID(t *testing.T) {
+	dir, err := ioutil.TempDir("", "jwt-keys")
+	assert.Nil(t, err)
+	defer os.RemoveAll(dir)
+
+	keyA, _ := GenerateKeyForMethod(ES256)
+	keyB, _ := GenerateKeyForMethod(ES256)
+	other, _ := GenerateKeyForMethod(ES256)
+	for name, key := range map[string]interface{}{"a.pem": keyA, "b.pem": keyB} {
+		data, _ := MarshalPublicKeyToPEM(key, PKIX)
+		assert.Nil(t, ioutil.WriteFile(filepath.Join(dir, name), data, 0600))
+	}
+	rsaPub, _ := ioutil.ReadFile("test/sample_key.pub")
+	assert.Nil(t, ioutil.WriteFile(filepath.Join(dir, "rsa.pem"), rsaPub, 0600))
+
+	provider, err := NewFileKeyProvider(dir, time.Hour, nil)
+	assert.Nil(t, err)
+	defer provider.Close()
+
+	// 两个ES256密钥都匹配，RSA密钥不是候选
+	key, err := provider.KeyFunc(New(ES256))
+	assert.Nil(t, err)
+	set, ok := key.(*VerificationKeySet)
+	assert.True(t, ok)
+	assert.Len(t, set.Keys, 2)
+
+	tokenString, _ := New(ES256).Generate(keyB)
+	token, err := Parse(tokenString, provider.KeyFunc)
+	assert.Nil(t, err)
+	pubB, _ := PublicKeyOf(keyB)
+	assert.DeepEqual(t, token.Key, pubB)
+
+	tokenString, _ = New(ES256).Generate(other)
+	_, err = Parse(tokenString, provider.KeyFunc)
+	assert.NotNil(t, err)
+	assert.DeepEqual(t, err.(*ValidationError).Errors, ValidationErrorSignatureInvalid)
+}
+
+func TestNewFileKeyProviderError(t *testing.T) {
+	_, err := NewFileKeyProvider(filepath.Join(os.TempDir(), "jwt-keys-does-not-exist"), 0, nil)
+	assert.NotNil(t, err)
+
+	dir, _ := ioutil.TempDir("", "jwt-keys")
+	defer os.RemoveAll(dir)
+	key, _ := GenerateKeyForMethod(ES256)
+	data, _ := MarshalPublicKeyToPEM(key, PKIX)
+	ioutil.WriteFile(filepath.Join(dir, "a.pem"), data, 0600)
+	ioutil.WriteFile(filepath.Join(dir, "a.pub"), data, 0600)
+
+	_, err = NewFileKeyProvider(dir, 0, nil)
+	assert.NotNil(t, err)
+}
+
+func waitFor(t *testing.T, cond func() bool) {
+	deadline := time.Now().Add(5 * time.Second)
+	for !cond() {
+		if time.Now().After(deadline) {
+			t.Fatal("condition not met before deadline")
+		}
+		time.Sleep(5 * time.Millisecond)
+	}
+}
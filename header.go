@@ -0,0 +1,110 @@
+package jwt
+
+import (
+	"fmt"
+	"strings"
+)
+
+// CriticalHeaderHandler 处理crit中列出的扩展头部参数，value为该参数在头部中的值。
+// 只有签名验证通过之后才会被调用，此时value来自已经验证的头部。返回错误时令牌被拒绝
+type CriticalHeaderHandler func(token *Token, value interface{}) error
+
+// registeredHeaders 是RFC 7515和RFC 7516定义的头部参数，它们不能出现在crit中
+var registeredHeaders = map[string]bool{
+	"alg": true, "jku": true, "jwk": true, "kid": true, "x5u": true, "x5c": true,
+	"x5t": true, "x5t#S256": true, "typ": true, "cty": true, "crit": true,
+	"enc": true, "zip": true,
+}
+
+// validateHeader 按照Parser的配置检查crit、typ和cty头部参数
+func (p *Parser) validateHeader(token *Token) error {
+	if err := p.validateCritical(token); err != nil {
+		return err
+	}
+
+	if len(p.ValidTypes) > 0 {
+		typ, _ := token.Header["typ"].(string)
+		if !mediaTypeIn(typ, p.ValidTypes) {
+			return NewValidationError(fmt.Sprintf("token typ %q is not accepted", typ), ValidationErrorHeaderType)
+		}
+	}
+
+	if len(p.ValidContentTypes) > 0 {
+		cty, _ := token.Header["cty"].(string)
+		if !mediaTypeIn(cty, p.ValidContentTypes) {
+			return NewValidationError(fmt.Sprintf("token cty %q is not accepted", cty), ValidationErrorContentType)
+		}
+	}
+
+	return nil
+}
+
+// validateCritical 实现RFC 7515 4.1.11：crit必须是非空的字符串数组，
+// 其中的参数必须出现在头部中且不能是已注册的参数，每个参数都必须有对应的处理函数。
+// 处理函数由runCriticalHandlers在签名验证之后调用
+func (p *Parser) validateCritical(token *Token) error {
+	value, ok := token.Header["crit"]
+	if !ok {
+		return nil
+	}
+
+	list, ok := value.([]interface{})
+	if !ok || len(list) == 0 {
+		return NewValidationError("crit must be a non-empty array", ValidationErrorCriticalHeader)
+	}
+
+	seen := make(map[string]bool, len(list))
+	for _, item := range list {
+		name, ok := item.(string)
+		if !ok || name == "" || seen[name] {
+			return NewValidationError("crit must contain unique header parameter names", ValidationErrorCriticalHeader)
+		}
+		seen[name] = true
+
+		if registeredHeaders[name] {
+			return NewValidationError(fmt.Sprintf("crit must not contain registered header %q", name), ValidationErrorCriticalHeader)
+		}
+		if _, ok := token.Header[name]; !ok {
+			return NewValidationError(fmt.Sprintf("critical header %q is missing", name), ValidationErrorCriticalHeader)
+		}
+		if handler := p.CriticalHeaders[name]; handler == nil {
+			return NewValidationError(fmt.Sprintf("critical header %q is not understood", name), ValidationErrorCriticalHeader)
+		}
+	}
+	return nil
+}
+
+// runCriticalHandlers 调用crit中参数的处理函数，crit已经由validateCritical检查过
+func (p *Parser) runCriticalHandlers(token *Token) error {
+	list, _ := token.Header["crit"].([]interface{})
+	for _, item := range list {
+		name := item.(string)
+		if err := p.CriticalHeaders[name](token, token.Header[name]); err != nil {
+			return &ValidationError{Inner: err, Errors: ValidationErrorCriticalHeader}
+		}
+	}
+	return nil
+}
+
+// normalizeMediaType 将typ或cty转换为小写并去掉"application/"前缀，
+// RFC 7515建议在不含其他"/"时省略该前缀
+func normalizeMediaType(s string) string {
+	s = strings.ToLower(strings.TrimSpace(s))
+	if rest := strings.TrimPrefix(s, "application/"); !strings.Contains(rest, "/") {
+		return rest
+	}
+	return s
+}
+
+func mediaTypeIn(s string, accepted []string) bool {
+	if s == "" {
+		return false
+	}
+	s = normalizeMediaType(s)
+	for _, a := range accepted {
+		if normalizeMediaType(a) == s {
+			return true
+		}
+	}
+	return false
+}
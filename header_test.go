@@ -0,0 +1,94 @@
+package jwt
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/gotoxu/assert"
+)
+
+func TestParserHeaderValidation(t *testing.T) {
+	key := []byte("this-is-a-32-byte-hs256-test-key")
+	keyFunc := func(*Token) (interface{}, error) { return key, nil }
+
+	exp := func(token *Token, value interface{}) error {
+		if _, ok := value.(float64); !ok {
+			return errors.New("exp header must be a number")
+		}
+		return nil
+	}
+
+	var tests = []struct {
+		name   string
+		header map[string]interface{}
+		parser *Parser
+		errors uint32
+	}{
+		{"no crit", map[string]interface{}{}, &Parser{}, 0},
+		{"unknown crit", map[string]interface{}{"crit": []string{"exp"}, "exp": 1}, &Parser{}, ValidationErrorCriticalHeader},
+		{"handled crit", map[string]interface{}{"crit": []string{"exp"}, "exp": 1}, &Parser{CriticalHeaders: map[string]CriticalHeaderHandler{"exp": exp}}, 0},
+		{"handler rejects", map[string]interface{}{"crit": []string{"exp"}, "exp": "soon"}, &Parser{CriticalHeaders: map[string]CriticalHeaderHandler{"exp": exp}}, ValidationErrorCriticalHeader},
+		{"crit parameter missing", map[string]interface{}{"crit": []string{"exp"}}, &Parser{CriticalHeaders: map[string]CriticalHeaderHandler{"exp": exp}}, ValidationErrorCriticalHeader},
+		{"crit registered header", map[string]interface{}{"crit": []string{"kid"}, "kid": "a"}, &Parser{}, ValidationErrorCriticalHeader},
+		{"crit empty", map[string]interface{}{"crit": []string{}}, &Parser{}, ValidationErrorCriticalHeader},
+		{"crit not array", map[string]interface{}{"crit": "exp", "exp": 1}, &Parser{}, ValidationErrorCriticalHeader},
+		{"crit duplicate", map[string]interface{}{"crit": []string{"exp", "exp"}, "exp": 1}, &Parser{CriticalHeaders: map[string]CriticalHeaderHandler{"exp": exp}}, ValidationErrorCriticalHeader},
+		{"typ accepted", map[string]interface{}{"typ": "JWT"}, &Parser{ValidTypes: []string{"jwt"}}, 0},
+		{"typ with prefix", map[string]interface{}{"typ": "application/at+JWT"}, &Parser{ValidTypes: []string{"at+jwt"}}, 0},
+		{"typ configured with prefix", map[string]interface{}{"typ": "at+jwt"}, &Parser{ValidTypes: []string{"application/at+jwt"}}, 0},
+		{"typ rejected", map[string]interface{}{"typ": "JWT"}, &Parser{ValidTypes: []string{"at+jwt"}}, ValidationErrorHeaderType},
+		{"typ missing", map[string]interface{}{"typ": nil}, &Parser{ValidTypes: []string{"jwt"}}, ValidationErrorHeaderType},
+		{"cty accepted", map[string]interface{}{"cty": "JWT"}, &Parser{ValidContentTypes: []string{"jwt"}}, 0},
+		{"cty rejected", map[string]interface{}{"cty": "text/plain"}, &Parser{ValidContentTypes: []string{"jwt"}}, ValidationErrorContentType},
+		{"cty missing", map[string]interface{}{}, &Parser{ValidContentTypes: []string{"jwt"}}, ValidationErrorContentType},
+	}
+
+	for _, test := range tests {
+		token := New(HS256Method)
+		for k, v := range test.header {
+			if v == nil {
+				delete(token.Header, k)
+				continue
+			}
+			token.Header[k] = v
+		}
+		tokenString, err := token.Generate(key)
+		assert.Nil(t, err, test.name)
+
+		parsed, err := test.parser.Parse(tokenString, keyFunc)
+		if test.errors == 0 {
+			assert.Nil(t, err, test.name)
+			assert.True(t, parsed.Valid, test.name)
+			continue
+		}
+		assert.NotNil(t, err, test.name)
+		assert.DeepEqual(t, err.(*ValidationError).Errors, test.errors, test.name)
+	}
+}
+
+func TestCriticalHeaderAfterSignature(t *testing.T) {
+	key := []byte("this-is-a-32-byte-hs256-test-key")
+	calls := 0
+	p := &Parser{CriticalHeaders: map[string]CriticalHeaderHandler{
+		"ext": func(*Token, interface{}) error { calls++; return nil },
+	}}
+
+	token := New(HS256Method)
+	token.Header["crit"] = []string{"ext"}
+	token.Header["ext"] = "value"
+	forged, err := token.Generate([]byte("another-32-byte-hs256-signing-key"))
+	assert.Nil(t, err)
+
+	// 签名无效时不调用处理函数
+	_, err = p.Parse(forged, func(*Token) (interface{}, error) { return key, nil })
+	assert.NotNil(t, err)
+	assert.DeepEqual(t, err.(*ValidationError).Errors, ValidationErrorSignatureInvalid)
+	assert.DeepEqual(t, calls, 0)
+
+	valid, err := token.Generate(key)
+	assert.Nil(t, err)
+	parsed, err := p.Parse(valid, func(*Token) (interface{}, error) { return key, nil })
+	assert.Nil(t, err)
+	assert.True(t, parsed.Valid)
+	assert.DeepEqual(t, calls, 1)
+}
@@ -38,6 +38,9 @@ func (m *HMACMethod) Algorithm() string {
 // Sign 实现签名方法
 func (m *HMACMethod) Sign(canonicalString string, key interface{}) (string, error) {
 	if keyBytes, ok := key.([]byte); ok {
+		if err := defaultKeyPolicy.Check(m, key); err != nil {
+			return "", err
+		}
 		if !m.Hash.Available() {
 			return "", ErrHashUnavailable
 		}
@@ -53,11 +56,19 @@ func (m *HMACMethod) Sign(canonicalString string, key interface{}) (string, erro
 
 // Verify 实现签名验证方法
 func (m *HMACMethod) Verify(canonicalString string, signature string, key interface{}) error {
+	return m.verifyWithPolicy(canonicalString, signature, key, defaultKeyPolicy)
+}
+
+func (m *HMACMethod) verifyWithPolicy(canonicalString string, signature string, key interface{}, policy *KeyPolicy) error {
 	keyBytes, ok := key.([]byte)
 	if !ok {
 		return ErrInvalidKeyType
 	}
 
+	if err := policy.Check(m, key); err != nil {
+		return err
+	}
+
 	sig, err := DecodeSegment(signature)
 	if err != nil {
 		return err
@@ -0,0 +1,265 @@
+package jwt
+
+import (
+	"bufio"
+	"context"
+	"errors"
+	"expvar"
+	"fmt"
+	"io"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+// DefaultLatencyBuckets 是PrometheusHook延迟直方图的默认桶上限，单位为秒
+var DefaultLatencyBuckets = []float64{.0001, .00025, .0005, .001, .0025, .005, .01, .025, .05, .1, .25, .5, 1}
+
+// PrometheusContentType 是Prometheus文本格式的Content-Type
+const PrometheusContentType = "text/plain; version=0.0.4; charset=utf-8"
+
+// errors
+var (
+	ErrExpvarNameInUse = errors.New("expvar name is already published and is not an *expvar.Map")
+)
+
+// metricAlgorithm 返回用作指标名称的算法，算法未知时为"unknown"
+func metricAlgorithm(event HookEvent) string {
+	if event.Algorithm == "" {
+		return "unknown"
+	}
+	return event.Algorithm
+}
+
+// ExpvarHook 将操作次数、失败次数和累计耗时发布到expvar，
+// 键的格式为"<operation>.<alg>.total"、"<operation>.<alg>.failures"、
+// "<operation>.<alg>.duration_ns"和"<operation>.failures.<reason>"
+type ExpvarHook struct {
+	vars *expvar.Map
+}
+
+// expvarLock 保证并发的NewExpvarHook不会重复发布同一个name
+var expvarLock sync.Mutex
+
+// NewExpvarHook 在name下发布一个expvar.Map，name已经发布为expvar.Map时复用它，
+// 已经发布为其他类型时返回ErrExpvarNameInUse
+func NewExpvarHook(name string) (*ExpvarHook, error) {
+	expvarLock.Lock()
+	defer expvarLock.Unlock()
+
+	switch v := expvar.Get(name).(type) {
+	case nil:
+		return &ExpvarHook{vars: expvar.NewMap(name)}, nil
+	case *expvar.Map:
+		return &ExpvarHook{vars: v}, nil
+	}
+	return nil, ErrExpvarNameInUse
+}
+
+// Map 返回发布的expvar.Map
+func (h *ExpvarHook) Map() *expvar.Map {
+	return h.vars
+}
+
+// OnStart 实现Hook接口
+func (h *ExpvarHook) OnStart(ctx context.Context, event HookEvent) {}
+
+// OnEnd 实现Hook接口
+func (h *ExpvarHook) OnEnd(ctx context.Context, event HookEvent) {
+	prefix := string(event.Operation) + "." + metricAlgorithm(event)
+	h.vars.Add(prefix+".total", 1)
+	h.vars.Add(prefix+".duration_ns", int64(event.Duration))
+	if event.Err == nil {
+		return
+	}
+
+	h.vars.Add(prefix+".failures", 1)
+	for _, reason := range failureReasons(event) {
+		h.vars.Add(string(event.Operation)+".failures."+reason, 1)
+	}
+}
+
+type operationKey struct {
+	operation Operation
+	algorithm string
+}
+
+type failureKey struct {
+	operation Operation
+	reason    string
+}
+
+type operationStats struct {
+	success uint64
+	failure uint64
+	// buckets 是累计计数，与PrometheusHook.buckets一一对应
+	buckets []uint64
+	sum     float64
+}
+
+// PrometheusHook 在内存中统计操作次数、失败原因和延迟直方图，
+// 并以Prometheus文本格式输出，不依赖Prometheus客户端库
+type PrometheusHook struct {
+	namespace string
+	buckets   []float64
+
+	mu         sync.Mutex
+	operations map[operationKey]*operationStats
+	failures   map[failureKey]uint64
+}
+
+// NewPrometheusHook 创建PrometheusHook，namespace为空时使用"jwt"，buckets为空时使用DefaultLatencyBuckets
+func NewPrometheusHook(namespace string, buckets []float64) *PrometheusHook {
+	if namespace == "" {
+		namespace = "jwt"
+	}
+	if len(buckets) == 0 {
+		buckets = DefaultLatencyBuckets
+	}
+	buckets = append([]float64(nil), buckets...)
+	sort.Float64s(buckets)
+
+	return &PrometheusHook{
+		namespace:  namespace,
+		buckets:    buckets,
+		operations: make(map[operationKey]*operationStats),
+		failures:   make(map[failureKey]uint64),
+	}
+}
+
+// OnStart 实现Hook接口
+func (h *PrometheusHook) OnStart(ctx context.Context, event HookEvent) {}
+
+// OnEnd 实现Hook接口
+func (h *PrometheusHook) OnEnd(ctx context.Context, event HookEvent) {
+	seconds := event.Duration.Seconds()
+	key := operationKey{event.Operation, metricAlgorithm(event)}
+
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	stats, ok := h.operations[key]
+	if !ok {
+		stats = &operationStats{buckets: make([]uint64, len(h.buckets))}
+		h.operations[key] = stats
+	}
+
+	for i, upper := range h.buckets {
+		if seconds <= upper {
+			stats.buckets[i]++
+		}
+	}
+	stats.sum += seconds
+
+	if event.Err == nil {
+		stats.success++
+		return
+	}
+	stats.failure++
+	for _, reason := range failureReasons(event) {
+		h.failures[failureKey{event.Operation, reason}]++
+	}
+}
+
+// WriteTo 以Prometheus文本格式输出所有指标，输出按标签排序
+func (h *PrometheusHook) WriteTo(w io.Writer) (int64, error) {
+	h.mu.Lock()
+	operations := make([]operationKey, 0, len(h.operations))
+	stats := make(map[operationKey]operationStats, len(h.operations))
+	for key, s := range h.operations {
+		operations = append(operations, key)
+		s := *s
+		s.buckets = append([]uint64(nil), s.buckets...)
+		stats[key] = s
+	}
+	failures := make([]failureKey, 0, len(h.failures))
+	failureCounts := make(map[failureKey]uint64, len(h.failures))
+	for key, n := range h.failures {
+		failures = append(failures, key)
+		failureCounts[key] = n
+	}
+	h.mu.Unlock()
+
+	sort.Slice(operations, func(i, j int) bool {
+		if operations[i].operation != operations[j].operation {
+			return operations[i].operation < operations[j].operation
+		}
+		return operations[i].algorithm < operations[j].algorithm
+	})
+	sort.Slice(failures, func(i, j int) bool {
+		if failures[i].operation != failures[j].operation {
+			return failures[i].operation < failures[j].operation
+		}
+		return failures[i].reason < failures[j].reason
+	})
+
+	cw := &countingWriter{w: bufio.NewWriter(w)}
+
+	name := h.namespace + "_operations_total"
+	fmt.Fprintf(cw, "# HELP %s Number of JWT operations by outcome.\n# TYPE %s counter\n", name, name)
+	for _, key := range operations {
+		s := stats[key]
+		labels := operationLabels(key)
+		fmt.Fprintf(cw, "%s{%s,outcome=\"success\"} %d\n", name, labels, s.success)
+		fmt.Fprintf(cw, "%s{%s,outcome=\"failure\"} %d\n", name, labels, s.failure)
+	}
+
+	name = h.namespace + "_failures_total"
+	fmt.Fprintf(cw, "# HELP %s Number of failed JWT operations by reason.\n# TYPE %s counter\n", name, name)
+	for _, key := range failures {
+		fmt.Fprintf(cw, "%s{operation=\"%s\",reason=\"%s\"} %d\n", name, escapeLabel(string(key.operation)), escapeLabel(key.reason), failureCounts[key])
+	}
+
+	name = h.namespace + "_operation_duration_seconds"
+	fmt.Fprintf(cw, "# HELP %s Latency of JWT operations.\n# TYPE %s histogram\n", name, name)
+	for _, key := range operations {
+		s := stats[key]
+		labels := operationLabels(key)
+		for i, upper := range h.buckets {
+			fmt.Fprintf(cw, "%s_bucket{%s,le=\"%s\"} %d\n", name, labels, strconv.FormatFloat(upper, 'g', -1, 64), s.buckets[i])
+		}
+		fmt.Fprintf(cw, "%s_bucket{%s,le=\"+Inf\"} %d\n", name, labels, s.success+s.failure)
+		fmt.Fprintf(cw, "%s_sum{%s} %s\n", name, labels, strconv.FormatFloat(s.sum, 'g', -1, 64))
+		fmt.Fprintf(cw, "%s_count{%s} %d\n", name, labels, s.success+s.failure)
+	}
+
+	if cw.err == nil {
+		cw.err = cw.w.Flush()
+	}
+	return cw.n, cw.err
+}
+
+// ServeHTTP 输出Prometheus文本格式的指标，可以直接挂载到/metrics
+func (h *PrometheusHook) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", PrometheusContentType)
+	h.WriteTo(w)
+}
+
+func operationLabels(key operationKey) string {
+	return fmt.Sprintf("operation=\"%s\",algorithm=\"%s\"", escapeLabel(string(key.operation)), escapeLabel(key.algorithm))
+}
+
+var labelEscaper = strings.NewReplacer(`\`, `\\`, `"`, `\"`, "\n", `\n`)
+
+func escapeLabel(value string) string {
+	return labelEscaper.Replace(value)
+}
+
+// countingWriter 记录写入的字节数和第一个错误
+type countingWriter struct {
+	w   *bufio.Writer
+	n   int64
+	err error
+}
+
+func (c *countingWriter) Write(p []byte) (int, error) {
+	if c.err != nil {
+		return 0, c.err
+	}
+	n, err := c.w.Write(p)
+	c.n += int64(n)
+	c.err = err
+	return n, err
+}
@@ -0,0 +1,199 @@
+package jwt
+
+import (
+	"context"
+	"sync"
+	"time"
+)
+
+// Operation 是Hook观察的操作
+type Operation string
+
+// Hook 观察的操作
+const (
+	// OpParse 是Parser验证一个令牌的完整过程，包括格式检查
+	OpParse Operation = "parse"
+	// OpSign 是Token.Generate中的签名
+	OpSign Operation = "sign"
+	// OpVerify 是签名验证
+	OpVerify Operation = "verify"
+	// OpKeyLookup 是调用KeyFunc查找验证密钥
+	OpKeyLookup Operation = "key_lookup"
+	// OpClaims 是claims验证
+	OpClaims Operation = "claims"
+)
+
+// HookEvent 描述一次操作，OnStart时只有Operation、Algorithm、KeyID和Start有效
+type HookEvent struct {
+	Operation Operation
+	// Algorithm 是签名算法，OpParse开始时还不知道算法，此时为空
+	Algorithm string
+	KeyID     string
+	Start     time.Time
+	Duration  time.Duration
+	// Err 为nil表示操作成功
+	Err error
+	// Errors 是失败时的ValidationError标志位，不是ValidationError的错误按操作类型归类
+	Errors uint32
+
+	// raw 是OpParse的令牌字符串，只供AuditLogger计算指纹，不对外暴露
+	raw string
+}
+
+// Hook 在签名和验证的各个阶段开始和结束时被调用，用于统计指标和日志。
+// 实现必须是并发安全的，并且不能阻塞
+type Hook interface {
+	OnStart(ctx context.Context, event HookEvent)
+	OnEnd(ctx context.Context, event HookEvent)
+}
+
+var (
+	hooksLock   sync.RWMutex
+	globalHooks []Hook
+)
+
+// hookEntry 使同一个Hook的多次注册可以分别取消，也不要求Hook的类型可以比较
+type hookEntry struct {
+	Hook
+}
+
+// RegisterHook 注册一个对所有Token.Generate和Parser生效的Hook，返回的函数用于取消注册
+func RegisterHook(hook Hook) (unregister func()) {
+	hooksLock.Lock()
+	defer hooksLock.Unlock()
+
+	// 总是复制，已经取得旧切片的调用者不受影响
+	entry := &hookEntry{hook}
+	globalHooks = append(globalHooks[:len(globalHooks):len(globalHooks)], entry)
+
+	var once sync.Once
+	return func() {
+		once.Do(func() {
+			hooksLock.Lock()
+			defer hooksLock.Unlock()
+
+			hooks := make([]Hook, 0, len(globalHooks))
+			for _, h := range globalHooks {
+				if h != Hook(entry) {
+					hooks = append(hooks, h)
+				}
+			}
+			globalHooks = hooks
+		})
+	}
+}
+
+func registeredHooks() []Hook {
+	hooksLock.RLock()
+	defer hooksLock.RUnlock()
+
+	return globalHooks
+}
+
+// hooks 返回全局Hook和Parser.Hooks
+func (p *Parser) hooks() []Hook {
+	global := registeredHooks()
+	if len(p.Hooks) == 0 {
+		return global
+	}
+	hooks := make([]Hook, 0, len(global)+len(p.Hooks))
+	return append(append(hooks, global...), p.Hooks...)
+}
+
+// hookRun 是一次正在进行的被观察操作
+type hookRun struct {
+	ctx   context.Context
+	hooks []Hook
+	event HookEvent
+	// errors 是非ValidationError错误对应的标志位
+	errors uint32
+}
+
+// startHooks 调用OnStart，没有Hook时返回nil
+func startHooks(ctx context.Context, hooks []Hook, op Operation, token *Token, errors uint32) *hookRun {
+	if len(hooks) == 0 {
+		return nil
+	}
+
+	r := &hookRun{
+		ctx:    ctx,
+		hooks:  hooks,
+		event:  HookEvent{Operation: op, Start: time.Now()},
+		errors: errors,
+	}
+	r.setToken(token)
+	for _, h := range hooks {
+		h.OnStart(ctx, r.event)
+	}
+	return r
+}
+
+func (r *hookRun) setToken(token *Token) {
+	if token == nil {
+		return
+	}
+	if token.Method != nil {
+		r.event.Algorithm = token.Method.Algorithm()
+	}
+	r.event.KeyID, _ = token.Header["kid"].(string)
+}
+
+func (r *hookRun) setRaw(raw string) {
+	if r != nil {
+		r.event.raw = raw
+	}
+}
+
+// end 记录结果并调用OnEnd
+func (r *hookRun) end(token *Token, err error) {
+	if r == nil {
+		return
+	}
+
+	r.setToken(token)
+	r.event.Duration = time.Since(r.event.Start)
+	r.event.Err = err
+	if ve, ok := err.(*ValidationError); ok {
+		r.event.Errors = ve.Errors
+	} else if err != nil {
+		r.event.Errors = r.errors
+	}
+
+	for _, h := range r.hooks {
+		h.OnEnd(r.ctx, r.event)
+	}
+}
+
+// validationReasons 是ValidationError标志位的名称，用作指标的标签
+var validationReasons = []struct {
+	bit  uint32
+	name string
+}{
+	{ValidationErrorMalformed, "malformed"},
+	{ValidationErrorUnverifiable, "unverifiable"},
+	{ValidationErrorSignatureInvalid, "signature_invalid"},
+	{ValidationErrorAudience, "audience"},
+	{ValidationErrorExpired, "expired"},
+	{ValidationErrorIssuedAt, "issued_at"},
+	{ValidationErrorIssuer, "issuer"},
+	{ValidationErrorNotValidYet, "not_valid_yet"},
+	{ValidationErrorID, "id"},
+	{ValidationErrorClaimsInvalid, "claims_invalid"},
+	{ValidationErrorHeaderType, "header_type"},
+	{ValidationErrorContentType, "content_type"},
+	{ValidationErrorCriticalHeader, "critical_header"},
+}
+
+// failureReasons 返回失败事件的原因，没有标志位时为"error"
+func failureReasons(event HookEvent) []string {
+	var reasons []string
+	for _, r := range validationReasons {
+		if event.Errors&r.bit != 0 {
+			reasons = append(reasons, r.name)
+		}
+	}
+	if len(reasons) == 0 {
+		reasons = append(reasons, "error")
+	}
+	return reasons
+}
@@ -0,0 +1,198 @@
+package jwt
+
+import (
+	"bytes"
+	"context"
+	"expvar"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/gotoxu/assert"
+)
+
+type recordingHook struct {
+	mu     sync.Mutex
+	starts []Operation
+	ends   []HookEvent
+}
+
+func (h *recordingHook) OnStart(ctx context.Context, event HookEvent) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	h.starts = append(h.starts, event.Operation)
+}
+
+func (h *recordingHook) OnEnd(ctx context.Context, event HookEvent) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	h.ends = append(h.ends, event)
+}
+
+func (h *recordingHook) operations() []Operation {
+	var ops []Operation
+	for _, e := range h.ends {
+		ops = append(ops, e.Operation)
+	}
+	return ops
+}
+
+func TestHooks(t *testing.T) {
+	key := []byte("this-is-a-32-byte-hs256-test-key")
+	keyFunc := func(*Token) (interface{}, error) { return key, nil }
+
+	global := &recordingHook{}
+	unregister := RegisterHook(global)
+
+	token := NewWithClaims(HS256Method, MapClaims{"sub": "alice"})
+	token.Header["kid"] = "k1"
+	tokenString, err := token.Generate(key)
+	assert.Nil(t, err)
+	assert.DeepEqual(t, global.starts, []Operation{OpSign})
+	assert.DeepEqual(t, global.ends[0].Algorithm, "HS256")
+	assert.DeepEqual(t, global.ends[0].KeyID, "k1")
+	assert.Nil(t, global.ends[0].Err)
+
+	local := &recordingHook{}
+	p := &Parser{Hooks: []Hook{local}}
+	_, err = p.Parse(tokenString, keyFunc)
+	assert.Nil(t, err)
+	assert.DeepEqual(t, local.starts, []Operation{OpParse, OpKeyLookup, OpClaims, OpVerify})
+	assert.DeepEqual(t, local.operations(), []Operation{OpKeyLookup, OpClaims, OpVerify, OpParse})
+	assert.DeepEqual(t, global.operations(), []Operation{OpSign, OpKeyLookup, OpClaims, OpVerify, OpParse})
+	for _, e := range local.ends {
+		assert.DeepEqual(t, e.Algorithm, "HS256")
+		assert.Nil(t, e.Err)
+	}
+
+	unregister()
+	unregister()
+
+	expired, _ := NewWithClaims(HS256Method, MapClaims{"exp": time.Now().Add(-time.Minute).Unix()}).Generate(key)
+	local = &recordingHook{}
+	p = &Parser{Hooks: []Hook{local}}
+	_, err = p.Parse(expired, keyFunc)
+	assert.NotNil(t, err)
+	assert.Len(t, global.ends, 5)
+	claims := local.ends[1]
+	assert.DeepEqual(t, claims.Operation, OpClaims)
+	assert.DeepEqual(t, claims.Errors, ValidationErrorExpired)
+	parse := local.ends[3]
+	assert.DeepEqual(t, parse.Operation, OpParse)
+	assert.DeepEqual(t, parse.Errors, ValidationErrorExpired)
+
+	local = &recordingHook{}
+	p = &Parser{Hooks: []Hook{local}}
+	_, err = p.Parse("not a token", keyFunc)
+	assert.NotNil(t, err)
+	assert.DeepEqual(t, local.operations(), []Operation{OpParse})
+	assert.DeepEqual(t, local.ends[0].Algorithm, "")
+	assert.DeepEqual(t, local.ends[0].Errors, ValidationErrorMalformed)
+}
+
+func TestMetricHooks(t *testing.T) {
+	key := []byte("this-is-a-32-byte-hs256-test-key")
+	keyFunc := func(*Token) (interface{}, error) { return key, nil }
+	valid, _ := NewWithClaims(HS256Method, MapClaims{}).Generate(key)
+	expired, _ := NewWithClaims(HS256Method, MapClaims{"exp": time.Now().Add(-time.Minute).Unix()}).Generate(key)
+
+	ev, err := NewExpvarHook("jwt_test_metrics")
+	assert.Nil(t, err)
+	again, err := NewExpvarHook("jwt_test_metrics")
+	assert.Nil(t, err)
+	assert.True(t, again.Map() == ev.Map())
+
+	if expvar.Get("jwt_test_metrics_int") == nil {
+		expvar.NewInt("jwt_test_metrics_int")
+	}
+	_, err = NewExpvarHook("jwt_test_metrics_int")
+	assert.DeepEqual(t, err, ErrExpvarNameInUse)
+	prom := NewPrometheusHook("", []float64{10, 1})
+
+	p := &Parser{Hooks: []Hook{ev, prom}}
+	p.Parse(valid, keyFunc)
+	p.Parse(expired, keyFunc)
+	p.Parse("a.b", keyFunc)
+
+	assert.DeepEqual(t, ev.Map().Get("parse.HS256.total").String(), "2")
+	assert.DeepEqual(t, ev.Map().Get("parse.HS256.failures").String(), "1")
+	assert.DeepEqual(t, ev.Map().Get("parse.unknown.failures").String(), "1")
+	assert.DeepEqual(t, ev.Map().Get("parse.failures.expired").String(), "1")
+	assert.DeepEqual(t, ev.Map().Get("parse.failures.malformed").String(), "1")
+	assert.DeepEqual(t, ev.Map().Get("verify.HS256.total").String(), "2")
+	assert.Nil(t, ev.Map().Get("verify.HS256.failures"))
+
+	var buf bytes.Buffer
+	n, err := prom.WriteTo(&buf)
+	assert.Nil(t, err)
+	assert.DeepEqual(t, n, int64(buf.Len()))
+
+	out := buf.String()
+	assert.StringContains(t, out, "# TYPE jwt_operations_total counter\n")
+	assert.StringContains(t, out, `jwt_operations_total{operation="parse",algorithm="HS256",outcome="success"} 1`)
+	assert.StringContains(t, out, `jwt_operations_total{operation="parse",algorithm="HS256",outcome="failure"} 1`)
+	assert.StringContains(t, out, `jwt_failures_total{operation="claims",reason="expired"} 1`)
+	assert.StringContains(t, out, `jwt_failures_total{operation="parse",reason="malformed"} 1`)
+	assert.StringContains(t, out, "# TYPE jwt_operation_duration_seconds histogram\n")
+	assert.StringContains(t, out, `jwt_operation_duration_seconds_bucket{operation="verify",algorithm="HS256",le="1"} 2`)
+	assert.StringContains(t, out, `jwt_operation_duration_seconds_bucket{operation="verify",algorithm="HS256",le="10"} 2`)
+	assert.StringContains(t, out, `jwt_operation_duration_seconds_bucket{operation="verify",algorithm="HS256",le="+Inf"} 2`)
+	assert.StringContains(t, out, `jwt_operation_duration_seconds_count{operation="verify",algorithm="HS256"} 2`)
+
+	rec := httptest.NewRecorder()
+	prom.ServeHTTP(rec, httptest.NewRequest("GET", "/metrics", nil))
+	assert.DeepEqual(t, rec.Header().Get("Content-Type"), PrometheusContentType)
+	assert.DeepEqual(t, rec.Body.String(), out)
+}
+
+func TestVerifierMetricHooks(t *testing.T) {
+	key := []byte("this-is-a-32-byte-hs256-test-key")
+	ev, err := NewExpvarHook("jwt_test_verifier_metrics")
+	assert.Nil(t, err)
+	ev.Map().Init()
+	prom := NewPrometheusHook("", nil)
+
+	v, err := NewVerifier(IssuerConfig{
+		Issuer:       "https://a.example.com",
+		ValidMethods: []string{"HS256"},
+		KeyFunc:      func(*Token) (interface{}, error) { return key, nil },
+		Audiences:    []string{"api"},
+		Leeway:       time.Minute,
+	})
+	assert.Nil(t, err)
+	v.Parser = &Parser{Hooks: []Hook{ev, prom}}
+
+	sign := func(claims MapClaims) string {
+		s, err := NewWithClaims(HS256Method, claims).Generate(key)
+		assert.Nil(t, err)
+		return s
+	}
+	_, err = v.Verify(sign(MapClaims{"iss": "https://a.example.com", "aud": "api"}))
+	assert.Nil(t, err)
+	_, err = v.Verify(sign(MapClaims{"iss": "https://evil.example.com", "aud": "api"}))
+	assert.NotNil(t, err)
+	_, err = v.Verify(sign(MapClaims{"iss": "https://a.example.com", "aud": "other"}))
+	assert.NotNil(t, err)
+	_, err = v.Verify(sign(MapClaims{"iss": "https://a.example.com", "aud": "api", "exp": time.Now().Add(-2 * time.Minute).Unix()}))
+	assert.NotNil(t, err)
+
+	// 未知签发者和签发者配置验证失败都计为失败的解析
+	assert.DeepEqual(t, ev.Map().Get("parse.HS256.total").String(), "4")
+	assert.DeepEqual(t, ev.Map().Get("parse.HS256.failures").String(), "3")
+	assert.DeepEqual(t, ev.Map().Get("parse.failures.issuer").String(), "1")
+	assert.DeepEqual(t, ev.Map().Get("parse.failures.audience").String(), "1")
+	assert.DeepEqual(t, ev.Map().Get("parse.failures.expired").String(), "1")
+	assert.DeepEqual(t, ev.Map().Get("claims.HS256.failures").String(), "2")
+	// 未知签发者在查找密钥之前就被拒绝
+	assert.DeepEqual(t, ev.Map().Get("key_lookup.HS256.total").String(), "3")
+
+	var buf bytes.Buffer
+	_, err = prom.WriteTo(&buf)
+	assert.Nil(t, err)
+	out := buf.String()
+	assert.StringContains(t, out, `jwt_operations_total{operation="parse",algorithm="HS256",outcome="success"} 1`)
+	assert.StringContains(t, out, `jwt_operations_total{operation="parse",algorithm="HS256",outcome="failure"} 3`)
+	assert.StringContains(t, out, `jwt_failures_total{operation="parse",reason="issuer"} 1`)
+	assert.StringContains(t, out, `jwt_failures_total{operation="claims",reason="audience"} 1`)
+}
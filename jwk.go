@@ -0,0 +1,434 @@
+package jwt
+
+import (
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/elliptic"
+	"crypto/rsa"
+	"crypto/sha256"
+	"encoding/json"
+	"errors"
+	"math/big"
+)
+
+// errors
+var (
+	ErrJWKUnsupportedKeyType = errors.New("jwk: unsupported key type")
+	ErrJWKUnsupportedCurve   = errors.New("jwk: unsupported elliptic curve")
+	ErrJWKInvalid            = errors.New("jwk: key is missing required parameters")
+	ErrJWKNotFound           = errors.New("jwk: no matching key found")
+)
+
+// JSONWebKey 表示RFC 7517定义的JSON Web Key
+type JSONWebKey struct {
+	KeyType   string   `json:"kty"`
+	Use       string   `json:"use,omitempty"`
+	KeyOps    []string `json:"key_ops,omitempty"`
+	Algorithm string   `json:"alg,omitempty"`
+	KeyID     string   `json:"kid,omitempty"`
+
+	// RSA
+	N  string `json:"n,omitempty"`
+	E  string `json:"e,omitempty"`
+	D  string `json:"d,omitempty"`
+	P  string `json:"p,omitempty"`
+	Q  string `json:"q,omitempty"`
+	DP string `json:"dp,omitempty"`
+	DQ string `json:"dq,omitempty"`
+	QI string `json:"qi,omitempty"`
+
+	// EC, OKP
+	Curve string `json:"crv,omitempty"`
+	X     string `json:"x,omitempty"`
+	Y     string `json:"y,omitempty"`
+
+	// oct
+	K string `json:"k,omitempty"`
+
+	X5C     []string `json:"x5c,omitempty"`
+	X5T     string   `json:"x5t,omitempty"`
+	X5TS256 string   `json:"x5t#S256,omitempty"`
+}
+
+// JSONWebKeySet 表示RFC 7517定义的JWK Set
+type JSONWebKeySet struct {
+	Keys []JSONWebKey `json:"keys"`
+}
+
+// ParseJWK 解析JSON编码的JWK
+func ParseJWK(data []byte) (*JSONWebKey, error) {
+	jwk := new(JSONWebKey)
+	if err := json.Unmarshal(data, jwk); err != nil {
+		return nil, err
+	}
+	if jwk.KeyType == "" {
+		return nil, ErrJWKInvalid
+	}
+	return jwk, nil
+}
+
+// ParseJWKS 解析JSON编码的JWK Set
+func ParseJWKS(data []byte) (*JSONWebKeySet, error) {
+	set := new(JSONWebKeySet)
+	if err := json.Unmarshal(data, set); err != nil {
+		return nil, err
+	}
+	return set, nil
+}
+
+// LookupKeyID 返回集合中所有kid与之相同的JWK
+func (s *JSONWebKeySet) LookupKeyID(kid string) []JSONWebKey {
+	var keys []JSONWebKey
+	for _, k := range s.Keys {
+		if k.KeyID == kid {
+			keys = append(keys, k)
+		}
+	}
+	return keys
+}
+
+// NewJSONWebKey 将RSA、ECDSA、Ed25519公私钥或HMAC密钥([]byte)转换为JWK
+func NewJSONWebKey(key interface{}) (*JSONWebKey, error) {
+	switch k := key.(type) {
+	case *rsa.PublicKey:
+		return &JSONWebKey{
+			KeyType: "RSA",
+			N:       EncodeSegment(k.N.Bytes()),
+			E:       EncodeSegment(big.NewInt(int64(k.E)).Bytes()),
+		}, nil
+	case *rsa.PrivateKey:
+		jwk, _ := NewJSONWebKey(&k.PublicKey)
+		jwk.D = EncodeSegment(k.D.Bytes())
+		if len(k.Primes) == 2 {
+			p, q := k.Primes[0], k.Primes[1]
+			one := big.NewInt(1)
+			jwk.P = EncodeSegment(p.Bytes())
+			jwk.Q = EncodeSegment(q.Bytes())
+			jwk.DP = EncodeSegment(new(big.Int).Mod(k.D, new(big.Int).Sub(p, one)).Bytes())
+			jwk.DQ = EncodeSegment(new(big.Int).Mod(k.D, new(big.Int).Sub(q, one)).Bytes())
+			jwk.QI = EncodeSegment(new(big.Int).ModInverse(q, p).Bytes())
+		}
+		return jwk, nil
+	case *ecdsa.PublicKey:
+		name, size := curveName(k.Curve)
+		if name == "" {
+			return nil, ErrJWKUnsupportedCurve
+		}
+		return &JSONWebKey{
+			KeyType: "EC",
+			Curve:   name,
+			X:       EncodeSegment(paddedBytes(k.X, size)),
+			Y:       EncodeSegment(paddedBytes(k.Y, size)),
+		}, nil
+	case *ecdsa.PrivateKey:
+		jwk, err := NewJSONWebKey(&k.PublicKey)
+		if err != nil {
+			return nil, err
+		}
+		_, size := curveName(k.Curve)
+		jwk.D = EncodeSegment(paddedBytes(k.D, size))
+		return jwk, nil
+	case ed25519.PublicKey:
+		return &JSONWebKey{KeyType: "OKP", Curve: "Ed25519", X: EncodeSegment(k)}, nil
+	case ed25519.PrivateKey:
+		jwk, _ := NewJSONWebKey(k.Public())
+		jwk.D = EncodeSegment(k.Seed())
+		return jwk, nil
+	case []byte:
+		return &JSONWebKey{KeyType: "oct", K: EncodeSegment(k)}, nil
+	}
+	return nil, ErrJWKUnsupportedKeyType
+}
+
+// Public 返回去除了私钥材料的JWK副本，对称密钥没有公开部分，返回nil
+func (k *JSONWebKey) Public() *JSONWebKey {
+	if k.KeyType == "oct" {
+		return nil
+	}
+
+	pub := *k
+	pub.D, pub.P, pub.Q, pub.DP, pub.DQ, pub.QI = "", "", "", "", "", ""
+	return &pub
+}
+
+// Thumbprint 计算RFC 7638定义的JWK SHA-256指纹，结果为base64url编码
+func (k *JSONWebKey) Thumbprint() (string, error) {
+	// 成员按字典序排列，且只包含必需成员
+	var members interface{}
+	switch k.KeyType {
+	case "RSA":
+		if k.N == "" || k.E == "" {
+			return "", ErrJWKInvalid
+		}
+		members = struct {
+			E   string `json:"e"`
+			Kty string `json:"kty"`
+			N   string `json:"n"`
+		}{k.E, k.KeyType, k.N}
+	case "EC":
+		if k.Curve == "" || k.X == "" || k.Y == "" {
+			return "", ErrJWKInvalid
+		}
+		members = struct {
+			Crv string `json:"crv"`
+			Kty string `json:"kty"`
+			X   string `json:"x"`
+			Y   string `json:"y"`
+		}{k.Curve, k.KeyType, k.X, k.Y}
+	case "OKP":
+		if k.Curve == "" || k.X == "" {
+			return "", ErrJWKInvalid
+		}
+		members = struct {
+			Crv string `json:"crv"`
+			Kty string `json:"kty"`
+			X   string `json:"x"`
+		}{k.Curve, k.KeyType, k.X}
+	case "oct":
+		if k.K == "" {
+			return "", ErrJWKInvalid
+		}
+		members = struct {
+			K   string `json:"k"`
+			Kty string `json:"kty"`
+		}{k.K, k.KeyType}
+	default:
+		return "", ErrJWKUnsupportedKeyType
+	}
+
+	data, err := json.Marshal(members)
+	if err != nil {
+		return "", err
+	}
+	sum := sha256.Sum256(data)
+	return EncodeSegment(sum[:]), nil
+}
+
+// Key 将JWK转换为可用于签名或验证的密钥:
+// RSA为*rsa.PublicKey或*rsa.PrivateKey，EC为*ecdsa.PublicKey或*ecdsa.PrivateKey，
+// OKP为ed25519.PublicKey或ed25519.PrivateKey，oct为[]byte
+func (k *JSONWebKey) Key() (interface{}, error) {
+	switch k.KeyType {
+	case "RSA":
+		return k.rsaKey()
+	case "EC":
+		return k.ecKey()
+	case "OKP":
+		return k.okpKey()
+	case "oct":
+		if k.K == "" {
+			return nil, ErrJWKInvalid
+		}
+		return DecodeSegment(k.K)
+	}
+	return nil, ErrJWKUnsupportedKeyType
+}
+
+// IsPrivate 判断JWK是否包含私钥或对称密钥材料
+func (k *JSONWebKey) IsPrivate() bool {
+	return k.D != "" || k.K != ""
+}
+
+func (k *JSONWebKey) rsaKey() (interface{}, error) {
+	n, err := decodeBigInt(k.N)
+	if err != nil {
+		return nil, err
+	}
+	e, err := decodeBigInt(k.E)
+	if err != nil {
+		return nil, err
+	}
+	if !e.IsInt64() || e.Int64() > 1<<31-1 {
+		return nil, ErrJWKInvalid
+	}
+
+	pub := rsa.PublicKey{N: n, E: int(e.Int64())}
+	if k.D == "" {
+		return &pub, nil
+	}
+
+	priv := &rsa.PrivateKey{PublicKey: pub}
+	if priv.D, err = decodeBigInt(k.D); err != nil {
+		return nil, err
+	}
+	if k.P != "" && k.Q != "" {
+		var p, q *big.Int
+		if p, err = decodeBigInt(k.P); err != nil {
+			return nil, err
+		}
+		if q, err = decodeBigInt(k.Q); err != nil {
+			return nil, err
+		}
+		priv.Primes = []*big.Int{p, q}
+	}
+	if err = priv.Validate(); err != nil {
+		return nil, err
+	}
+	priv.Precompute()
+	return priv, nil
+}
+
+func (k *JSONWebKey) ecKey() (interface{}, error) {
+	curve := curveByName(k.Curve)
+	if curve == nil {
+		return nil, ErrJWKUnsupportedCurve
+	}
+
+	x, err := decodeBigInt(k.X)
+	if err != nil {
+		return nil, err
+	}
+	y, err := decodeBigInt(k.Y)
+	if err != nil {
+		return nil, err
+	}
+	if !curve.IsOnCurve(x, y) {
+		return nil, ErrJWKInvalid
+	}
+
+	pub := ecdsa.PublicKey{Curve: curve, X: x, Y: y}
+	if k.D == "" {
+		return &pub, nil
+	}
+
+	d, err := decodeBigInt(k.D)
+	if err != nil {
+		return nil, err
+	}
+	return &ecdsa.PrivateKey{PublicKey: pub, D: d}, nil
+}
+
+func (k *JSONWebKey) okpKey() (interface{}, error) {
+	if k.Curve != "Ed25519" {
+		return nil, ErrJWKUnsupportedCurve
+	}
+
+	x, err := DecodeSegment(k.X)
+	if err != nil {
+		return nil, err
+	}
+	if len(x) != ed25519.PublicKeySize {
+		return nil, ErrJWKInvalid
+	}
+	if k.D == "" {
+		return ed25519.PublicKey(x), nil
+	}
+
+	d, err := DecodeSegment(k.D)
+	if err != nil {
+		return nil, err
+	}
+	if len(d) != ed25519.SeedSize {
+		return nil, ErrJWKInvalid
+	}
+	return ed25519.NewKeyFromSeed(d), nil
+}
+
+func curveByName(name string) elliptic.Curve {
+	switch name {
+	case "P-256":
+		return elliptic.P256()
+	case "P-384":
+		return elliptic.P384()
+	case "P-521":
+		return elliptic.P521()
+	}
+	return nil
+}
+
+func curveName(curve elliptic.Curve) (string, int) {
+	switch curve.Params().Name {
+	case "P-256":
+		return "P-256", 32
+	case "P-384":
+		return "P-384", 48
+	case "P-521":
+		return "P-521", 66
+	}
+	return "", 0
+}
+
+func paddedBytes(n *big.Int, size int) []byte {
+	b := n.Bytes()
+	if len(b) >= size {
+		return b
+	}
+	padded := make([]byte, size)
+	copy(padded[size-len(b):], b)
+	return padded
+}
+
+func decodeBigInt(s string) (*big.Int, error) {
+	if s == "" {
+		return nil, ErrJWKInvalid
+	}
+	b, err := DecodeSegment(s)
+	if err != nil {
+		return nil, err
+	}
+	return new(big.Int).SetBytes(b), nil
+}
+
+// KeyMatchesMethod 判断密钥类型是否可以用于指定的签名方法，未知的签名方法总是返回true
+func KeyMatchesMethod(method SigningMethod, key interface{}) bool {
+	switch method.(type) {
+	case *HMACMethod:
+		_, ok := key.([]byte)
+		return ok
+	case *RSAMethod, *RSAPSSMethod:
+		switch key.(type) {
+		case *rsa.PublicKey, *rsa.PrivateKey:
+			return true
+		}
+	case *ECDSAMethod:
+		switch key.(type) {
+		case *ecdsa.PublicKey, *ecdsa.PrivateKey:
+			return true
+		}
+	case *EdDSAMethod:
+		switch key.(type) {
+		case ed25519.PublicKey, ed25519.PrivateKey:
+			return true
+		}
+	default:
+		return true
+	}
+	return false
+}
+
+// selectKey 从JWK Set中为令牌选择验证密钥：令牌带有kid时按kid查找，
+// 否则返回与签名算法匹配的密钥，有多个候选时返回VerificationKeySet
+func (s *JSONWebKeySet) selectKey(token *Token) (interface{}, error) {
+	kid, _ := token.Header["kid"].(string)
+
+	var found []interface{}
+	for i := range s.Keys {
+		jwk := &s.Keys[i]
+		if kid != "" && jwk.KeyID != kid {
+			continue
+		}
+		if jwk.Use != "" && jwk.Use != "sig" {
+			continue
+		}
+		if jwk.Algorithm != "" && token.Method != nil && jwk.Algorithm != token.Method.Algorithm() {
+			continue
+		}
+
+		key, err := jwk.Key()
+		if err != nil {
+			continue
+		}
+		if token.Method != nil && !KeyMatchesMethod(token.Method, key) {
+			continue
+		}
+		found = append(found, key)
+	}
+
+	switch len(found) {
+	case 0:
+		return nil, ErrJWKNotFound
+	case 1:
+		return found[0], nil
+	}
+	return &VerificationKeySet{Keys: found}, nil
+}
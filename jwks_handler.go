@@ -0,0 +1,113 @@
+package jwt
+
+import (
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// JWKSContentType 是RFC 7517定义的JWK Set媒体类型
+const JWKSContentType = "application/jwk-set+json"
+
+// DefaultJWKSMaxAge 是JWKSHandler默认的缓存时间
+const DefaultJWKSMaxAge = 5 * time.Minute
+
+// KeySource 提供要发布的JWK Set，RotatingKeySet和JSONWebKeySet都实现了该接口
+type KeySource interface {
+	JWKS() (*JSONWebKeySet, error)
+}
+
+// JWKS 返回密钥集自身，使静态的JSONWebKeySet可以作为KeySource
+func (s *JSONWebKeySet) JWKS() (*JSONWebKeySet, error) {
+	return s, nil
+}
+
+// JWKSHandler 是发布JWK Set的http.Handler，
+// 它只输出公钥部分，并支持ETag和条件GET
+type JWKSHandler struct {
+	Source KeySource
+	// MaxAge 是Cache-Control中的max-age，为0时使用DefaultJWKSMaxAge
+	MaxAge time.Duration
+	// ErrorHandler 在读取密钥集失败时被调用，可以为nil
+	ErrorHandler func(error)
+}
+
+// NewJWKSHandler 创建一个JWKSHandler
+func NewJWKSHandler(source KeySource) *JWKSHandler {
+	return &JWKSHandler{Source: source}
+}
+
+func (h *JWKSHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet && r.Method != http.MethodHead {
+		w.Header().Set("Allow", "GET, HEAD")
+		http.Error(w, http.StatusText(http.StatusMethodNotAllowed), http.StatusMethodNotAllowed)
+		return
+	}
+
+	body, err := h.marshal()
+	if err != nil {
+		if h.ErrorHandler != nil {
+			h.ErrorHandler(err)
+		}
+		http.Error(w, http.StatusText(http.StatusInternalServerError), http.StatusInternalServerError)
+		return
+	}
+
+	sum := sha256.Sum256(body)
+	etag := `"` + base64.RawURLEncoding.EncodeToString(sum[:16]) + `"`
+
+	maxAge := h.MaxAge
+	if maxAge <= 0 {
+		maxAge = DefaultJWKSMaxAge
+	}
+
+	header := w.Header()
+	header.Set("Cache-Control", "public, max-age="+strconv.Itoa(int(maxAge/time.Second)))
+	header.Set("ETag", etag)
+
+	if etagMatches(r.Header.Get("If-None-Match"), etag) {
+		w.WriteHeader(http.StatusNotModified)
+		return
+	}
+
+	header.Set("Content-Type", JWKSContentType)
+	header.Set("Content-Length", strconv.Itoa(len(body)))
+	w.WriteHeader(http.StatusOK)
+	if r.Method != http.MethodHead {
+		w.Write(body)
+	}
+}
+
+// marshal 编码密钥集，私钥只输出其公钥部分，对称密钥被忽略
+func (h *JWKSHandler) marshal() ([]byte, error) {
+	set, err := h.Source.JWKS()
+	if err != nil {
+		return nil, err
+	}
+
+	public := JSONWebKeySet{Keys: []JSONWebKey{}}
+	for i := range set.Keys {
+		if key := set.Keys[i].Public(); key != nil {
+			public.Keys = append(public.Keys, *key)
+		}
+	}
+	return json.Marshal(public)
+}
+
+// etagMatches 判断If-None-Match中是否包含etag，比较时忽略弱校验前缀
+func etagMatches(header, etag string) bool {
+	if header == "" {
+		return false
+	}
+	for _, candidate := range strings.Split(header, ",") {
+		candidate = strings.TrimSpace(candidate)
+		if candidate == "*" || strings.TrimPrefix(candidate, "W/") == etag {
+			return true
+		}
+	}
+	return false
+}
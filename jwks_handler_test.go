@@ -0,0 +1,100 @@
+package jwt
+
+import (
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/gotoxu/assert"
+)
+
+type failingKeySource struct{}
+
+func (failingKeySource) JWKS() (*JSONWebKeySet, error) {
+	return nil, errors.New("unavailable")
+}
+
+func TestJWKSHandler(t *testing.T) {
+	ecKey, _ := GenerateKeyForMethod(ES256)
+	secret, _ := GenerateHMACSecret(32)
+	private, _ := NewJSONWebKey(ecKey)
+	private.KeyID = "ec"
+	oct, _ := NewJSONWebKey(secret)
+
+	handler := NewJWKSHandler(&JSONWebKeySet{Keys: []JSONWebKey{*private, *oct}})
+	server := httptest.NewServer(handler)
+	defer server.Close()
+
+	resp, err := http.Get(server.URL)
+	assert.Nil(t, err)
+	assert.DeepEqual(t, resp.StatusCode, http.StatusOK)
+	assert.DeepEqual(t, resp.Header.Get("Content-Type"), JWKSContentType)
+	assert.DeepEqual(t, resp.Header.Get("Cache-Control"), "public, max-age=300")
+
+	set, err := (&RemoteKeySet{URL: server.URL, Client: http.DefaultClient}).KeySet()
+	assert.Nil(t, err)
+	assert.DeepEqual(t, len(set.Keys), 1)
+	assert.DeepEqual(t, set.Keys[0].KeyID, "ec")
+	assert.False(t, set.Keys[0].IsPrivate())
+
+	etag := resp.Header.Get("ETag")
+	assert.NotEmpty(t, etag)
+	resp.Body.Close()
+
+	var tests = []struct {
+		method      string
+		ifNoneMatch string
+		status      int
+	}{
+		{http.MethodGet, etag, http.StatusNotModified},
+		{http.MethodGet, `W/` + etag, http.StatusNotModified},
+		{http.MethodGet, `"other", ` + etag, http.StatusNotModified},
+		{http.MethodGet, `"other"`, http.StatusOK},
+		{http.MethodHead, "", http.StatusOK},
+		{http.MethodPost, "", http.StatusMethodNotAllowed},
+	}
+
+	for _, test := range tests {
+		req := httptest.NewRequest(test.method, "/", nil)
+		if test.ifNoneMatch != "" {
+			req.Header.Set("If-None-Match", test.ifNoneMatch)
+		}
+		rec := httptest.NewRecorder()
+		handler.ServeHTTP(rec, req)
+		assert.DeepEqual(t, rec.Code, test.status)
+		if test.method == http.MethodHead {
+			assert.DeepEqual(t, rec.Body.Len(), 0)
+		}
+	}
+}
+
+func TestJWKSHandlerRotatingKeySet(t *testing.T) {
+	key, _ := GenerateKeyForMethod(ES256)
+	set, _ := NewRotatingKeySet(RotatingKey{KeyID: "first", Method: ES256, Key: key})
+	handler := &JWKSHandler{Source: set, MaxAge: time.Minute}
+
+	rec := httptest.NewRecorder()
+	handler.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/", nil))
+	assert.DeepEqual(t, rec.Code, http.StatusOK)
+	assert.DeepEqual(t, rec.Header().Get("Cache-Control"), "public, max-age=60")
+	first := rec.Header().Get("ETag")
+
+	next, _ := GenerateKeyForMethod(ES256)
+	set.Add(RotatingKey{KeyID: "second", Method: ES256, Key: next})
+
+	req := httptest.NewRequest(http.MethodGet, "/", nil)
+	req.Header.Set("If-None-Match", first)
+	rec = httptest.NewRecorder()
+	handler.ServeHTTP(rec, req)
+	assert.DeepEqual(t, rec.Code, http.StatusOK)
+	assert.NotDeepEqual(t, rec.Header().Get("ETag"), first)
+
+	var handled error
+	failing := &JWKSHandler{Source: failingKeySource{}, ErrorHandler: func(err error) { handled = err }}
+	rec = httptest.NewRecorder()
+	failing.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/", nil))
+	assert.DeepEqual(t, rec.Code, http.StatusInternalServerError)
+	assert.NotNil(t, handled)
+}
@@ -0,0 +1,223 @@
+package jwt
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"sync"
+	"time"
+)
+
+// 远程JWK Set的默认缓存参数
+const (
+	DefaultJWKSCacheTTL        = time.Hour
+	DefaultJWKSRefreshInterval = time.Minute
+
+	maxRemoteDocumentSize = 1 << 20
+)
+
+// RemoteKeySet 从jwks_uri获取并缓存JWK Set，用作Parse的KeyFunc
+type RemoteKeySet struct {
+	URL    string
+	Client *http.Client
+	// CacheTTL 是缓存的有效期，为0时使用DefaultJWKSCacheTTL
+	CacheTTL time.Duration
+	// RefreshInterval 是遇到未知kid时两次强制刷新之间的最小间隔，为0时使用DefaultJWKSRefreshInterval。
+	// 刷新失败时继续使用旧的缓存，重试间隔从RefreshInterval开始加倍，最长为CacheTTL
+	RefreshInterval time.Duration
+
+	mu        sync.Mutex
+	set       *JSONWebKeySet
+	fetchedAt time.Time
+	// failures 是连续刷新失败的次数，retryAt 之前不再请求而是直接返回旧的缓存
+	failures int
+	retryAt  time.Time
+	// fetching 是正在进行的请求，同一时间只有一个请求，其他调用者等待它完成
+	fetching *remoteFetch
+}
+
+// remoteFetch 是一次获取JWK Set的请求，done关闭后set和err有效
+type remoteFetch struct {
+	done chan struct{}
+	set  *JSONWebKeySet
+	err  error
+	// cancelled 表示发起请求的调用者的ctx已取消，等待者应该自己重新请求
+	cancelled bool
+}
+
+// NewRemoteKeySet 创建一个远程JWK Set，client为空时使用http.DefaultClient
+func NewRemoteKeySet(url string, client *http.Client) *RemoteKeySet {
+	return &RemoteKeySet{URL: url, Client: client}
+}
+
+// KeyFunc 实现KeyFunc，可以直接传递给Parse
+func (r *RemoteKeySet) KeyFunc(token *Token) (interface{}, error) {
+	return r.KeyFuncContext(context.Background(), token)
+}
+
+// KeyFuncContext 实现KeyFuncContext，获取JWK Set的请求在ctx取消时中止
+func (r *RemoteKeySet) KeyFuncContext(ctx context.Context, token *Token) (interface{}, error) {
+	set, err := r.keySet(ctx, false)
+	if err != nil {
+		return nil, err
+	}
+
+	key, err := set.selectKey(token)
+	if err != ErrJWKNotFound {
+		return key, err
+	}
+
+	// 密钥可能已经轮换，在允许的频率内重新获取一次
+	if set, err = r.keySet(ctx, true); err != nil {
+		return nil, err
+	}
+	return set.selectKey(token)
+}
+
+// KeySet 返回当前缓存的JWK Set，必要时从远端获取
+func (r *RemoteKeySet) KeySet() (*JSONWebKeySet, error) {
+	return r.keySet(context.Background(), false)
+}
+
+// KeySetContext 与KeySet相同，获取JWK Set的请求在ctx取消时中止
+func (r *RemoteKeySet) KeySetContext(ctx context.Context) (*JSONWebKeySet, error) {
+	return r.keySet(ctx, false)
+}
+
+// keySet 返回缓存的JWK Set，必要时获取。请求在锁外进行，
+// 并发的调用者共享同一个请求，等待时可以通过自己的ctx取消
+func (r *RemoteKeySet) keySet(ctx context.Context, force bool) (*JSONWebKeySet, error) {
+	for {
+		r.mu.Lock()
+		if set := r.cached(force); set != nil {
+			r.mu.Unlock()
+			return set, nil
+		}
+
+		if f := r.fetching; f != nil {
+			r.mu.Unlock()
+			select {
+			case <-f.done:
+			case <-ctx.Done():
+				return nil, ctx.Err()
+			}
+			if f.cancelled && ctx.Err() == nil {
+				continue
+			}
+			return f.set, f.err
+		}
+
+		f := &remoteFetch{done: make(chan struct{})}
+		r.fetching = f
+		stale := r.set
+		r.mu.Unlock()
+
+		r.fetch(ctx, f, stale)
+		return f.set, f.err
+	}
+}
+
+// cached 返回仍然可用的缓存，调用者必须持有r.mu
+func (r *RemoteKeySet) cached(force bool) *JSONWebKeySet {
+	if r.set == nil {
+		return nil
+	}
+	now := TimeFunc()
+	if now.Before(r.retryAt) {
+		return r.set
+	}
+	age := now.Sub(r.fetchedAt)
+	if !force && age < r.cacheTTL() {
+		return r.set
+	}
+	if force && age < r.refreshInterval() {
+		return r.set
+	}
+	return nil
+}
+
+// fetch 执行请求并把结果通知等待者，stale是请求失败时返回的旧缓存
+func (r *RemoteKeySet) fetch(ctx context.Context, f *remoteFetch, stale *JSONWebKeySet) {
+	now := TimeFunc()
+	data, err := fetchDocument(ctx, r.Client, r.URL)
+	var set *JSONWebKeySet
+	if err == nil {
+		set, err = ParseJWKS(data)
+	}
+	f.cancelled = ctx.Err() != nil
+
+	r.mu.Lock()
+	switch {
+	case err == nil:
+		r.set = set
+		r.fetchedAt = now
+		r.failures = 0
+		r.retryAt = time.Time{}
+		f.set = set
+	case stale != nil && !f.cancelled:
+		// 记录失败并退避，期间直接返回旧的缓存，不在每次查找时请求不可用的端点
+		r.failures++
+		r.retryAt = now.Add(r.retryBackoff())
+		f.set = stale
+	default:
+		// 调用者取消时不能用旧的缓存掩盖错误
+		f.err = err
+	}
+	r.fetching = nil
+	r.mu.Unlock()
+	close(f.done)
+}
+
+// retryBackoff 返回连续失败后的重试间隔，调用者必须持有r.mu
+func (r *RemoteKeySet) retryBackoff() time.Duration {
+	backoff, max := r.refreshInterval(), r.cacheTTL()
+	for i := 1; i < r.failures && backoff < max; i++ {
+		backoff *= 2
+	}
+	if backoff > max {
+		backoff = max
+	}
+	return backoff
+}
+
+func (r *RemoteKeySet) cacheTTL() time.Duration {
+	if r.CacheTTL > 0 {
+		return r.CacheTTL
+	}
+	return DefaultJWKSCacheTTL
+}
+
+func (r *RemoteKeySet) refreshInterval() time.Duration {
+	if r.RefreshInterval > 0 {
+		return r.RefreshInterval
+	}
+	return DefaultJWKSRefreshInterval
+}
+
+// fetchDocument 使用GET请求获取一个JSON文档
+func fetchDocument(ctx context.Context, client *http.Client, url string) ([]byte, error) {
+	if client == nil {
+		client = http.DefaultClient
+	}
+
+	req, err := http.NewRequestWithContext(ctx, "GET", url, nil)
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Accept", "application/json")
+
+	resp, err := client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		io.Copy(ioutil.Discard, io.LimitReader(resp.Body, maxRemoteDocumentSize))
+		return nil, fmt.Errorf("unexpected status %s fetching %s", resp.Status, url)
+	}
+
+	return ioutil.ReadAll(io.LimitReader(resp.Body, maxRemoteDocumentSize))
+}
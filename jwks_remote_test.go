@@ -0,0 +1,91 @@
+package jwt
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/gotoxu/assert"
+)
+
+func TestRemoteKeySetStaleBackoff(t *testing.T) {
+	pub := loadRSAPublicKeyFromDisk("test/sample_key.pub")
+	jwk, err := NewJSONWebKey(pub)
+	assert.Nil(t, err)
+	jwk.KeyID = "sample"
+	body, err := json.Marshal(JSONWebKeySet{Keys: []JSONWebKey{*jwk}})
+	assert.Nil(t, err)
+
+	var requests, failing int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt32(&requests, 1)
+		if atomic.LoadInt32(&failing) != 0 {
+			http.Error(w, "unavailable", http.StatusServiceUnavailable)
+			return
+		}
+		w.Write(body)
+	}))
+	defer server.Close()
+
+	defer func() { TimeFunc = time.Now }()
+	now := time.Now()
+	TimeFunc = func() time.Time { return now }
+
+	keys := NewRemoteKeySet(server.URL, server.Client())
+	keys.CacheTTL = 10 * time.Minute
+	keys.RefreshInterval = time.Minute
+	token := New(RS256)
+	token.Header["kid"] = "sample"
+	unknown := New(RS256)
+	unknown.Header["kid"] = "unknown"
+
+	lookup := func() {
+		key, err := keys.KeyFunc(token)
+		assert.Nil(t, err)
+		assert.NotNil(t, key)
+		keys.KeyFunc(unknown)
+	}
+
+	lookup()
+	assert.DeepEqual(t, atomic.LoadInt32(&requests), int32(1))
+
+	// 缓存过期后端点不可用：返回旧的缓存，并在退避期间不再请求
+	atomic.StoreInt32(&failing, 1)
+	now = now.Add(11 * time.Minute)
+	lookup()
+	assert.DeepEqual(t, atomic.LoadInt32(&requests), int32(2))
+	for i := 0; i < 10; i++ {
+		lookup()
+	}
+	assert.DeepEqual(t, atomic.LoadInt32(&requests), int32(2))
+
+	// 重试间隔加倍
+	var tests = []struct {
+		advance  time.Duration
+		requests int32
+	}{
+		{time.Minute, 3},
+		{time.Minute, 3},
+		{time.Minute, 4},
+		{3 * time.Minute, 4},
+		{time.Minute, 5},
+	}
+	for i, test := range tests {
+		now = now.Add(test.advance)
+		lookup()
+		assert.DeepEqual(t, atomic.LoadInt32(&requests), test.requests, i)
+	}
+
+	// 恢复后重新按CacheTTL缓存
+	atomic.StoreInt32(&failing, 0)
+	now = now.Add(10 * time.Minute)
+	lookup()
+	assert.DeepEqual(t, atomic.LoadInt32(&requests), int32(6))
+	now = now.Add(5 * time.Minute)
+	_, err = keys.KeyFunc(token)
+	assert.Nil(t, err)
+	assert.DeepEqual(t, atomic.LoadInt32(&requests), int32(6))
+}
@@ -0,0 +1,66 @@
+package jwttest
+
+import (
+	"testing"
+
+	"github.com/blockcdn-go/jwt"
+)
+
+// AssertValid 断言令牌通过了验证
+func AssertValid(tb testing.TB, token *jwt.Token, err error) {
+	tb.Helper()
+
+	if err != nil {
+		tb.Fatalf("jwttest: expected a valid token, got error: %v", err)
+	}
+	if token == nil || !token.Valid {
+		tb.Fatalf("jwttest: expected a valid token")
+	}
+}
+
+// RequireValidationError 断言err是*jwt.ValidationError并返回它
+func RequireValidationError(tb testing.TB, err error) *jwt.ValidationError {
+	tb.Helper()
+
+	if err == nil {
+		tb.Fatalf("jwttest: expected a validation error, got nil")
+	}
+	ve, ok := err.(*jwt.ValidationError)
+	if !ok {
+		tb.Fatalf("jwttest: expected *jwt.ValidationError, got %T: %v", err, err)
+	}
+	return ve
+}
+
+// AssertErrors 断言err是设置了errors中所有标志位的ValidationError，也可以设置其他标志位
+func AssertErrors(tb testing.TB, err error, errors uint32) {
+	tb.Helper()
+
+	ve := RequireValidationError(tb, err)
+	if ve.Errors&errors != errors {
+		tb.Fatalf("jwttest: expected validation error bits %#x to include %#x: %v", ve.Errors, errors, err)
+	}
+}
+
+// AssertOnlyErrors 断言err是标志位恰好为errors的ValidationError
+func AssertOnlyErrors(tb testing.TB, err error, errors uint32) {
+	tb.Helper()
+
+	ve := RequireValidationError(tb, err)
+	if ve.Errors != errors {
+		tb.Fatalf("jwttest: expected validation error bits %#x, got %#x: %v", errors, ve.Errors, err)
+	}
+}
+
+// AssertNotErrors 断言err为nil，或者是没有设置errors中任何标志位的ValidationError
+func AssertNotErrors(tb testing.TB, err error, errors uint32) {
+	tb.Helper()
+
+	if err == nil {
+		return
+	}
+	ve := RequireValidationError(tb, err)
+	if ve.Errors&errors != 0 {
+		tb.Fatalf("jwttest: expected validation error bits %#x to exclude %#x: %v", ve.Errors, errors, err)
+	}
+}
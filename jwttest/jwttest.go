@@ -0,0 +1,260 @@
+// Package jwttest 提供测试中签发和验证JWT的辅助工具：
+// 为所有算法生成临时密钥的Issuer、可以覆盖claims和头部的Mint选项、
+// 基于httptest的JWKS和Discovery服务，以及检查ValidationError标志位的断言函数
+package jwttest
+
+import (
+	"crypto/rand"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"sort"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/blockcdn-go/jwt"
+)
+
+// Algorithms 是Issuer生成密钥的算法
+var Algorithms = []string{
+	"HS256", "HS384", "HS512",
+	"RS256", "RS384", "RS512",
+	"PS256", "PS384", "PS512",
+	"ES256", "ES384", "ES512",
+	"EdDSA",
+}
+
+// DefaultSubject 和DefaultAudience 是Mint默认使用的sub和aud
+const (
+	DefaultSubject  = "subject"
+	DefaultAudience = "https://api.example.com"
+	// OtherAudience 是WrongAudience选项使用的aud
+	OtherAudience = "https://wrong.example.com"
+	// DefaultLifetime 是Mint签发令牌的默认有效期
+	DefaultLifetime = time.Hour
+)
+
+// Issuer 是一个模拟的签发者，为Algorithms中的每个算法生成临时密钥，
+// 并通过httptest服务发布JWKS和OpenID Connect Discovery文档。
+// 每个算法的kid就是算法名称，RS*和PS*共享同一个RSA密钥
+type Issuer struct {
+	// URL 是签发者标识，即httptest服务的地址
+	URL string
+	// Audience 是Mint默认使用的aud
+	Audience string
+
+	server *httptest.Server
+	mu     sync.RWMutex
+	keys   map[string]interface{}
+}
+
+// NewIssuer 生成所有算法的密钥并启动JWKS服务，测试结束时必须调用Close
+func NewIssuer(tb testing.TB) *Issuer {
+	tb.Helper()
+
+	i := &Issuer{Audience: DefaultAudience, keys: make(map[string]interface{})}
+
+	rsaKey, err := jwt.GenerateRSAKey(jwt.DefaultRSAKeyBits)
+	if err != nil {
+		tb.Fatalf("jwttest: generate RSA key: %v", err)
+	}
+	for _, alg := range Algorithms {
+		method := jwt.GetSigningMethod(alg)
+		if method == nil {
+			tb.Fatalf("jwttest: signing method %s is not registered", alg)
+		}
+
+		var key interface{} = rsaKey
+		if !isRSA(method) {
+			if key, err = jwt.GenerateKeyForMethod(method); err != nil {
+				tb.Fatalf("jwttest: generate %s key: %v", alg, err)
+			}
+		}
+		i.keys[alg] = key
+	}
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("/.well-known/openid-configuration", i.serveDiscovery)
+	mux.Handle("/jwks.json", jwt.NewJWKSHandler(i))
+	i.server = httptest.NewServer(mux)
+	i.URL = i.server.URL
+	return i
+}
+
+// Close 关闭JWKS服务
+func (i *Issuer) Close() {
+	i.server.Close()
+}
+
+// Server 返回发布JWKS和Discovery文档的httptest服务
+func (i *Issuer) Server() *httptest.Server {
+	return i.server
+}
+
+// JWKSURL 返回JWKS的地址
+func (i *Issuer) JWKSURL() string {
+	return i.URL + "/jwks.json"
+}
+
+// Key 返回算法的签名密钥
+func (i *Issuer) Key(alg string) interface{} {
+	i.mu.RLock()
+	defer i.mu.RUnlock()
+
+	return i.keys[alg]
+}
+
+// VerificationKey 返回算法的验证密钥，HMAC算法返回共享密钥
+func (i *Issuer) VerificationKey(alg string) interface{} {
+	key := i.Key(alg)
+	if key == nil {
+		return nil
+	}
+	if _, ok := key.([]byte); ok {
+		return key
+	}
+	pub, err := jwt.PublicKeyOf(key)
+	if err != nil {
+		return nil
+	}
+	return pub
+}
+
+// Rotate 为算法生成新的密钥，之前签发的令牌将无法验证。
+// RS*和PS*共享同一个RSA密钥，轮换其中任何一个算法会同时替换这六个算法的密钥
+func (i *Issuer) Rotate(tb testing.TB, alg string) {
+	tb.Helper()
+
+	method := jwt.GetSigningMethod(alg)
+	if method == nil {
+		tb.Fatalf("jwttest: signing method %s is not registered", alg)
+	}
+	key, err := jwt.GenerateKeyForMethod(method)
+	if err != nil {
+		tb.Fatalf("jwttest: generate %s key: %v", alg, err)
+	}
+
+	i.mu.Lock()
+	defer i.mu.Unlock()
+	if !isRSA(method) {
+		i.keys[alg] = key
+		return
+	}
+	for _, a := range Algorithms {
+		if isRSA(jwt.GetSigningMethod(a)) {
+			i.keys[a] = key
+		}
+	}
+}
+
+func isRSA(method jwt.SigningMethod) bool {
+	switch method.(type) {
+	case *jwt.RSAMethod, *jwt.RSAPSSMethod:
+		return true
+	}
+	return false
+}
+
+// KeyFunc 根据kid返回验证密钥，可以直接传递给Parse
+func (i *Issuer) KeyFunc(token *jwt.Token) (interface{}, error) {
+	kid, _ := token.Header["kid"].(string)
+	if key := i.VerificationKey(kid); key != nil {
+		return key, nil
+	}
+	return nil, jwt.ErrJWKNotFound
+}
+
+// JWKS 返回所有非对称密钥的公钥，实现jwt.KeySource
+func (i *Issuer) JWKS() (*jwt.JSONWebKeySet, error) {
+	set := &jwt.JSONWebKeySet{Keys: []jwt.JSONWebKey{}}
+	for _, alg := range Algorithms {
+		key := i.VerificationKey(alg)
+		if _, ok := key.([]byte); ok || key == nil {
+			continue
+		}
+
+		jwk, err := jwt.NewJSONWebKey(key)
+		if err != nil {
+			return nil, err
+		}
+		jwk.KeyID = alg
+		jwk.Algorithm = alg
+		jwk.Use = "sig"
+		set.Keys = append(set.Keys, *jwk)
+	}
+	return set, nil
+}
+
+func (i *Issuer) serveDiscovery(w http.ResponseWriter, r *http.Request) {
+	var algs []string
+	for _, alg := range Algorithms {
+		if _, ok := i.Key(alg).([]byte); !ok {
+			algs = append(algs, alg)
+		}
+	}
+	sort.Strings(algs)
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(jwt.ProviderMetadata{
+		Issuer:                           i.URL,
+		JWKSURI:                          i.JWKSURL(),
+		IDTokenSigningAlgValuesSupported: algs,
+	})
+}
+
+// Mint 使用算法的密钥签发令牌，默认包含iss、sub、aud、iat、nbf、exp和jti，
+// 头部包含kid。opts可以覆盖这些值或构造各种无效令牌
+func (i *Issuer) Mint(tb testing.TB, alg string, opts ...Option) string {
+	tb.Helper()
+
+	method := jwt.GetSigningMethod(alg)
+	key := i.Key(alg)
+	if method == nil || key == nil {
+		tb.Fatalf("jwttest: issuer has no key for %s", alg)
+	}
+
+	now := jwt.TimeFunc()
+	m := &mint{
+		claims: jwt.MapClaims{
+			"iss": i.URL,
+			"sub": DefaultSubject,
+			"aud": i.Audience,
+			"iat": now.Unix(),
+			"nbf": now.Unix(),
+			"exp": now.Add(DefaultLifetime).Unix(),
+			"jti": randomID(tb),
+		},
+		header: map[string]interface{}{"kid": alg},
+		now:    now,
+	}
+	for _, opt := range opts {
+		opt(m)
+	}
+
+	token := jwt.NewWithClaims(method, m.claims)
+	for name, value := range m.header {
+		if value == nil {
+			delete(token.Header, name)
+			continue
+		}
+		token.Header[name] = value
+	}
+
+	tokenString, err := token.Generate(key)
+	if err != nil {
+		tb.Fatalf("jwttest: sign %s token: %v", alg, err)
+	}
+	if m.tamper {
+		tokenString = tamperSignature(tokenString)
+	}
+	return tokenString
+}
+
+func randomID(tb testing.TB) string {
+	b := make([]byte, 16)
+	if _, err := rand.Read(b); err != nil {
+		tb.Fatalf("jwttest: generate jti: %v", err)
+	}
+	return jwt.EncodeSegment(b)
+}
@@ -0,0 +1,96 @@
+package jwttest
+
+import (
+	"testing"
+
+	"github.com/blockcdn-go/jwt"
+	"github.com/gotoxu/assert"
+)
+
+func TestIssuerMint(t *testing.T) {
+	issuer := NewIssuer(t)
+	defer issuer.Close()
+
+	for _, alg := range Algorithms {
+		token, err := jwt.Parse(issuer.Mint(t, alg), issuer.KeyFunc)
+		AssertValid(t, token, err)
+		assert.DeepEqual(t, token.Header["kid"], alg, alg)
+		assert.DeepEqual(t, token.Claims.(jwt.MapClaims)["iss"], issuer.URL, alg)
+		assert.DeepEqual(t, token.Claims.(jwt.MapClaims)["sub"], DefaultSubject, alg)
+	}
+
+	var tests = []struct {
+		name   string
+		alg    string
+		opts   []Option
+		errors uint32
+	}{
+		{"expired", "ES256", []Option{Expired()}, jwt.ValidationErrorExpired},
+		{"future nbf", "RS256", []Option{NotYetValid()}, jwt.ValidationErrorNotValidYet},
+		{"tampered signature", "EdDSA", []Option{TamperedSignature()}, jwt.ValidationErrorSignatureInvalid},
+		{"tampered HMAC", "HS256", []Option{TamperedSignature()}, jwt.ValidationErrorSignatureInvalid},
+		{"wrong alg", "RS256", []Option{WrongAlgorithm("HS256")}, jwt.ValidationErrorSignatureInvalid},
+		{"unknown kid", "PS256", []Option{WithHeader("kid", "missing")}, jwt.ValidationErrorUnverifiable},
+		{"overridden exp", "HS384", []Option{WithClaim("exp", 1)}, jwt.ValidationErrorExpired},
+	}
+
+	for _, test := range tests {
+		_, err := jwt.Parse(issuer.Mint(t, test.alg, test.opts...), issuer.KeyFunc)
+		AssertOnlyErrors(t, err, test.errors)
+	}
+
+	// MapClaims不检查aud，需要Verifier
+	verifier, err := jwt.NewVerifier(jwt.IssuerConfig{
+		Issuer:       issuer.URL,
+		ValidMethods: Algorithms,
+		KeyFunc:      issuer.KeyFunc,
+		Audiences:    []string{DefaultAudience},
+	})
+	assert.Nil(t, err)
+	token, err := verifier.Verify(issuer.Mint(t, "ES384"))
+	AssertValid(t, token, err)
+	_, err = verifier.Verify(issuer.Mint(t, "ES384", WrongAudience(), Expired()))
+	AssertErrors(t, err, jwt.ValidationErrorAudience)
+	AssertNotErrors(t, err, jwt.ValidationErrorSignatureInvalid)
+}
+
+func TestIssuerServer(t *testing.T) {
+	issuer := NewIssuer(t)
+	defer issuer.Close()
+
+	set, err := issuer.JWKS()
+	assert.Nil(t, err)
+	assert.Len(t, set.Keys, 10)
+
+	keys := jwt.NewRemoteKeySet(issuer.JWKSURL(), issuer.Server().Client())
+	for _, alg := range []string{"RS512", "PS384", "ES512", "EdDSA"} {
+		token, err := jwt.Parse(issuer.Mint(t, alg), keys.KeyFunc)
+		AssertValid(t, token, err)
+	}
+
+	// HMAC密钥不会发布
+	_, err = jwt.Parse(issuer.Mint(t, "HS256"), keys.KeyFunc)
+	AssertErrors(t, err, jwt.ValidationErrorUnverifiable)
+
+	provider, err := jwt.NewProvider(issuer.URL, issuer.Server().Client())
+	assert.Nil(t, err)
+	claims, err := provider.VerifyIDToken(issuer.Mint(t, "ES256", WithAudience("client")), "client")
+	assert.Nil(t, err)
+	assert.DeepEqual(t, claims.Subject, DefaultSubject)
+
+	old := issuer.Mint(t, "ES256")
+	issuer.Rotate(t, "ES256")
+	_, err = jwt.Parse(old, issuer.KeyFunc)
+	AssertOnlyErrors(t, err, jwt.ValidationErrorSignatureInvalid)
+
+	// 轮换任何一个RSA算法都会替换RS*和PS*共享的密钥
+	oldRS, oldPS := issuer.Mint(t, "RS256"), issuer.Mint(t, "PS512")
+	issuer.Rotate(t, "PS256")
+	for _, old := range []string{oldRS, oldPS} {
+		_, err = jwt.Parse(old, issuer.KeyFunc)
+		AssertOnlyErrors(t, err, jwt.ValidationErrorSignatureInvalid)
+	}
+	assert.True(t, issuer.Key("RS384") == issuer.Key("PS256"))
+	token, err := jwt.Parse(issuer.Mint(t, "RS512"), issuer.KeyFunc)
+	AssertValid(t, token, err)
+}
@@ -0,0 +1,112 @@
+package jwttest
+
+import (
+	"strings"
+	"time"
+
+	"github.com/blockcdn-go/jwt"
+)
+
+// mint 是Mint正在构造的令牌
+type mint struct {
+	claims jwt.MapClaims
+	header map[string]interface{}
+	now    time.Time
+	tamper bool
+}
+
+// Option 修改Mint签发的令牌
+type Option func(*mint)
+
+// WithClaim 设置或覆盖一个claim
+func WithClaim(name string, value interface{}) Option {
+	return func(m *mint) {
+		m.claims[name] = value
+	}
+}
+
+// WithClaims 设置或覆盖多个claim
+func WithClaims(claims map[string]interface{}) Option {
+	return func(m *mint) {
+		for name, value := range claims {
+			m.claims[name] = value
+		}
+	}
+}
+
+// WithoutClaim 删除一个默认的claim
+func WithoutClaim(name string) Option {
+	return func(m *mint) {
+		delete(m.claims, name)
+	}
+}
+
+// WithHeader 设置或覆盖一个头部参数，value为nil时删除该参数
+func WithHeader(name string, value interface{}) Option {
+	return func(m *mint) {
+		m.header[name] = value
+	}
+}
+
+// WithAudience 设置aud
+func WithAudience(aud ...string) Option {
+	return func(m *mint) {
+		if len(aud) == 1 {
+			m.claims["aud"] = aud[0]
+			return
+		}
+		m.claims["aud"] = aud
+	}
+}
+
+// WithLifetime 设置exp为签发时间之后d
+func WithLifetime(d time.Duration) Option {
+	return func(m *mint) {
+		m.claims["exp"] = m.now.Add(d).Unix()
+	}
+}
+
+// Expired 签发一个一分钟前已经过期的令牌
+func Expired() Option {
+	return func(m *mint) {
+		m.claims["iat"] = m.now.Add(-DefaultLifetime).Unix()
+		m.claims["nbf"] = m.now.Add(-DefaultLifetime).Unix()
+		m.claims["exp"] = m.now.Add(-time.Minute).Unix()
+	}
+}
+
+// NotYetValid 签发一个nbf在一小时之后的令牌
+func NotYetValid() Option {
+	return func(m *mint) {
+		m.claims["nbf"] = m.now.Add(time.Hour).Unix()
+		m.claims["exp"] = m.now.Add(time.Hour + DefaultLifetime).Unix()
+	}
+}
+
+// WrongAudience 签发一个aud为OtherAudience的令牌
+func WrongAudience() Option {
+	return WithAudience(OtherAudience)
+}
+
+// WrongAlgorithm 使头部的alg与实际签名的算法不同，用于测试算法混淆
+func WrongAlgorithm(alg string) Option {
+	return WithHeader("alg", alg)
+}
+
+// TamperedSignature 在签名之后修改签名的第一个字节
+func TamperedSignature() Option {
+	return func(m *mint) {
+		m.tamper = true
+	}
+}
+
+// tamperSignature 翻转签名第一个字节的最低位
+func tamperSignature(tokenString string) string {
+	i := strings.LastIndex(tokenString, ".")
+	sig, err := jwt.DecodeSegment(tokenString[i+1:])
+	if err != nil || len(sig) == 0 {
+		return tokenString + "AA"
+	}
+	sig[0] ^= 1
+	return tokenString[:i+1] + jwt.EncodeSegment(sig)
+}
@@ -0,0 +1,108 @@
+package jwt
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rsa"
+	"fmt"
+)
+
+// DefaultMinRSAKeyBits 是默认策略要求的RSA模数最小位数
+const DefaultMinRSAKeyBits = 2048
+
+// KeyPolicy 是签名和验证时对密钥强度的要求。
+// 签名总是使用DefaultKeyPolicy；Parser.KeyPolicy可以为验证旧令牌放宽要求
+type KeyPolicy struct {
+	// MinRSAKeyBits 是RSA模数的最小位数，为0时不检查
+	MinRSAKeyBits int
+	// MinHMACKeySize 是HMAC密钥的最小字节数，为0时不检查
+	MinHMACKeySize int
+	// HMACKeyAtLeastHashSize 为true时HMAC密钥不能短于哈希函数的输出长度(RFC 7518 3.2)
+	HMACKeyAtLeastHashSize bool
+	// ECCurves 是每个ECDSA算法允许的曲线，算法不在其中时不检查
+	ECCurves map[string][]elliptic.Curve
+}
+
+// KeyPolicyError 表示密钥不满足KeyPolicy
+type KeyPolicyError struct {
+	Algorithm string
+	Reason    string
+}
+
+func (e *KeyPolicyError) Error() string {
+	return fmt.Sprintf("key does not satisfy the policy for %s: %s", e.Algorithm, e.Reason)
+}
+
+// DefaultKeyPolicy 返回默认的密钥策略：RSA至少2048位，HMAC密钥不短于哈希输出，
+// ES256、ES384和ES512分别只能使用P-256、P-384和P-521
+func DefaultKeyPolicy() *KeyPolicy {
+	return &KeyPolicy{
+		MinRSAKeyBits:          DefaultMinRSAKeyBits,
+		HMACKeyAtLeastHashSize: true,
+		ECCurves: map[string][]elliptic.Curve{
+			"ES256": {elliptic.P256()},
+			"ES384": {elliptic.P384()},
+			"ES512": {elliptic.P521()},
+		},
+	}
+}
+
+var defaultKeyPolicy = DefaultKeyPolicy()
+
+// Check 检查密钥是否满足策略，密钥类型与算法不匹配时由签名方法报告错误
+func (p *KeyPolicy) Check(method SigningMethod, key interface{}) error {
+	switch m := method.(type) {
+	case *HMACMethod:
+		secret, ok := key.([]byte)
+		if !ok {
+			return nil
+		}
+		if len(secret) < p.MinHMACKeySize {
+			return &KeyPolicyError{m.Algorithm(), fmt.Sprintf("HMAC key is %d bytes, at least %d required", len(secret), p.MinHMACKeySize)}
+		}
+		if p.HMACKeyAtLeastHashSize && len(secret) < m.Hash.Size() {
+			return &KeyPolicyError{m.Algorithm(), fmt.Sprintf("HMAC key is %d bytes, at least %d required", len(secret), m.Hash.Size())}
+		}
+
+	case *RSAMethod, *RSAPSSMethod:
+		var bits int
+		switch k := key.(type) {
+		case *rsa.PublicKey:
+			bits = k.N.BitLen()
+		case *rsa.PrivateKey:
+			bits = k.N.BitLen()
+		default:
+			return nil
+		}
+		if bits < p.MinRSAKeyBits {
+			return &KeyPolicyError{method.Algorithm(), fmt.Sprintf("RSA key is %d bits, at least %d required", bits, p.MinRSAKeyBits)}
+		}
+
+	case *ECDSAMethod:
+		var curve elliptic.Curve
+		switch k := key.(type) {
+		case *ecdsa.PublicKey:
+			curve = k.Curve
+		case *ecdsa.PrivateKey:
+			curve = k.Curve
+		default:
+			return nil
+		}
+		allowed, ok := p.ECCurves[m.Algorithm()]
+		if !ok {
+			return nil
+		}
+		for _, c := range allowed {
+			if c == curve {
+				return nil
+			}
+		}
+		return &KeyPolicyError{m.Algorithm(), fmt.Sprintf("curve %s is not allowed", curve.Params().Name)}
+	}
+	return nil
+}
+
+// policyVerifier 由内置签名方法实现，使Parser可以使用自己的KeyPolicy验证签名
+type policyVerifier interface {
+	verifyWithPolicy(signingString, signature string, key interface{}, policy *KeyPolicy) error
+}
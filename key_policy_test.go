@@ -0,0 +1,85 @@
+package jwt
+
+import (
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"testing"
+
+	"github.com/gotoxu/assert"
+)
+
+func TestKeyPolicySign(t *testing.T) {
+	weakRSA, err := rsa.GenerateKey(rand.Reader, 1024)
+	assert.Nil(t, err)
+	p384, err := ecdsa.GenerateKey(elliptic.P384(), rand.Reader)
+	assert.Nil(t, err)
+
+	var tests = []struct {
+		name   string
+		method SigningMethod
+		key    interface{}
+		valid  bool
+	}{
+		{"short HMAC key", HS256Method, []byte("secret"), false},
+		{"HMAC key shorter than hash", HS512Method, make([]byte, 32), false},
+		{"HMAC key", HS256Method, make([]byte, 32), true},
+		{"1024 bit RSA", RS256, weakRSA, false},
+		{"1024 bit RSA-PSS", PS256, weakRSA, false},
+		{"ES256 with P-384", ES256, p384, false},
+		{"ES384 with P-384", ES384, p384, true},
+	}
+
+	for _, test := range tests {
+		_, err := New(test.method).Generate(test.key)
+		if test.valid {
+			assert.Nil(t, err, test.name)
+			continue
+		}
+		assert.NotNil(t, err, test.name)
+		assert.IsType(t, &KeyPolicyError{}, err, test.name)
+	}
+}
+
+func TestKeyPolicyVerify(t *testing.T) {
+	legacy := []byte("secret")
+	weakRSA, err := rsa.GenerateKey(rand.Reader, 1024)
+	assert.Nil(t, err)
+
+	hmacToken := EncodeSegment([]byte(`{"alg":"HS256","typ":"JWT"}`)) + "." + EncodeSegment([]byte(`{"sub":"legacy"}`))
+	mac := hmac.New(sha256.New, legacy)
+	mac.Write([]byte(hmacToken))
+	hmacToken += "." + EncodeSegment(mac.Sum(nil))
+
+	rsaToken := EncodeSegment([]byte(`{"alg":"RS256","typ":"JWT"}`)) + "." + EncodeSegment([]byte(`{"sub":"legacy"}`))
+	digest := sha256.Sum256([]byte(rsaToken))
+	sig, err := rsa.SignPKCS1v15(rand.Reader, weakRSA, crypto.SHA256, digest[:])
+	assert.Nil(t, err)
+	rsaToken += "." + EncodeSegment(sig)
+
+	var tests = []struct {
+		name  string
+		token string
+		key   interface{}
+	}{
+		{"HMAC", hmacToken, legacy},
+		{"RSA", rsaToken, &weakRSA.PublicKey},
+	}
+
+	for _, test := range tests {
+		keyFunc := func(*Token) (interface{}, error) { return test.key, nil }
+
+		_, err := Parse(test.token, keyFunc)
+		assert.NotNil(t, err, test.name)
+		assert.DeepEqual(t, err.(*ValidationError).Errors, ValidationErrorSignatureInvalid, test.name)
+		assert.IsType(t, &KeyPolicyError{}, err.(*ValidationError).Inner, test.name)
+
+		token, err := (&Parser{KeyPolicy: &KeyPolicy{}}).Parse(test.token, keyFunc)
+		assert.Nil(t, err, test.name)
+		assert.True(t, token.Valid, test.name)
+	}
+}
@@ -0,0 +1,241 @@
+package jwt
+
+import (
+	"errors"
+	"sort"
+	"sync"
+	"time"
+)
+
+// errors
+var (
+	ErrNoActiveKey       = errors.New("key rotation: no active signing key")
+	ErrDuplicateKeyID    = errors.New("key rotation: duplicate kid")
+	ErrRotatingKeyMethod = errors.New("key rotation: key does not match its signing method")
+	ErrSymmetricKeyID    = errors.New("key rotation: symmetric keys require an explicit kid")
+)
+
+// RotatingKey 是轮换密钥集中的一个签名密钥。
+// 密钥在ActivateAt之后用于签名，在RetireAt之后不再签名但仍可用于验证，在ExpireAt之后被移除
+type RotatingKey struct {
+	// KeyID 为空时使用公钥的RFC 7638指纹。HMAC密钥的指纹是密钥本身的摘要，
+	// 不能写入令牌头部，因此必须显式设置
+	KeyID  string
+	Method SigningMethod
+	// Key 是签名用的私钥或HMAC密钥
+	Key interface{}
+
+	ActivateAt time.Time
+	// RetireAt 为零值时一直可以签名，直到更晚激活的密钥取代它
+	RetireAt time.Time
+	// ExpireAt 为零值时永不过期
+	ExpireAt time.Time
+}
+
+func (k *RotatingKey) active(now time.Time) bool {
+	return !now.Before(k.ActivateAt) && (k.RetireAt.IsZero() || now.Before(k.RetireAt)) && !k.expired(now)
+}
+
+func (k *RotatingKey) expired(now time.Time) bool {
+	return !k.ExpireAt.IsZero() && !now.Before(k.ExpireAt)
+}
+
+// RotatingKeySet 是按时间表轮换的签名密钥集合。
+// 签名总是使用当前激活的密钥中最晚激活的一个，并在头部写入其kid；
+// 所有未过期的密钥(包括尚未激活的下一个密钥)都会发布到JWKS并可用于验证
+type RotatingKeySet struct {
+	mu   sync.RWMutex
+	keys []*RotatingKey
+}
+
+// NewRotatingKeySet 创建一个轮换密钥集
+func NewRotatingKeySet(keys ...RotatingKey) (*RotatingKeySet, error) {
+	s := &RotatingKeySet{}
+	for _, key := range keys {
+		if err := s.Add(key); err != nil {
+			return nil, err
+		}
+	}
+	return s, nil
+}
+
+// Add 向密钥集中添加一个密钥，kid重复时返回ErrDuplicateKeyID，
+// HMAC密钥没有kid时返回ErrSymmetricKeyID
+func (s *RotatingKeySet) Add(key RotatingKey) error {
+	if key.Method == nil || !KeyMatchesMethod(key.Method, key.Key) {
+		return ErrRotatingKeyMethod
+	}
+
+	if key.KeyID == "" {
+		jwk, err := NewJSONWebKey(key.Key)
+		if err != nil {
+			return err
+		}
+		pub := jwk.Public()
+		if pub == nil {
+			return ErrSymmetricKeyID
+		}
+		if key.KeyID, err = pub.Thumbprint(); err != nil {
+			return err
+		}
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	for _, k := range s.keys {
+		if k.KeyID == key.KeyID {
+			return ErrDuplicateKeyID
+		}
+	}
+	s.keys = append(s.keys, &key)
+	sort.SliceStable(s.keys, func(i, j int) bool {
+		return s.keys[i].ActivateAt.Before(s.keys[j].ActivateAt)
+	})
+	return nil
+}
+
+// Remove 删除指定kid的密钥
+func (s *RotatingKeySet) Remove(kid string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	for i, k := range s.keys {
+		if k.KeyID == kid {
+			s.keys = append(s.keys[:i], s.keys[i+1:]...)
+			return
+		}
+	}
+}
+
+// Prune 删除所有已过期的密钥
+func (s *RotatingKeySet) Prune() {
+	now := TimeFunc()
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	keys := s.keys[:0]
+	for _, k := range s.keys {
+		if !k.expired(now) {
+			keys = append(keys, k)
+		}
+	}
+	s.keys = keys
+}
+
+// Active 返回当前用于签名的密钥
+func (s *RotatingKeySet) Active() (RotatingKey, error) {
+	now := TimeFunc()
+
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	for i := len(s.keys) - 1; i >= 0; i-- {
+		if s.keys[i].active(now) {
+			return *s.keys[i], nil
+		}
+	}
+	return RotatingKey{}, ErrNoActiveKey
+}
+
+// Sign 使用当前激活的密钥签名令牌，令牌的签名方法、alg和kid会被替换为该密钥的值
+func (s *RotatingKeySet) Sign(token *Token) (string, error) {
+	key, err := s.Active()
+	if err != nil {
+		return "", err
+	}
+
+	token.Method = key.Method
+	token.Header["alg"] = key.Method.Algorithm()
+	token.Header["kid"] = key.KeyID
+	return token.Generate(key.Key)
+}
+
+// SignClaims 使用当前激活的密钥签名claims
+func (s *RotatingKeySet) SignClaims(claims Claims) (string, error) {
+	key, err := s.Active()
+	if err != nil {
+		return "", err
+	}
+
+	token := NewWithClaims(key.Method, claims)
+	token.Header["kid"] = key.KeyID
+	return token.Generate(key.Key)
+}
+
+// JWKS 返回所有未过期密钥的公钥部分，HMAC密钥不会被发布
+func (s *RotatingKeySet) JWKS() (*JSONWebKeySet, error) {
+	now := TimeFunc()
+
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	set := &JSONWebKeySet{Keys: []JSONWebKey{}}
+	for i := len(s.keys) - 1; i >= 0; i-- {
+		k := s.keys[i]
+		if k.expired(now) {
+			continue
+		}
+
+		jwk, err := NewJSONWebKey(k.Key)
+		if err != nil {
+			return nil, err
+		}
+		if jwk = jwk.Public(); jwk == nil {
+			continue
+		}
+		jwk.KeyID = k.KeyID
+		jwk.Algorithm = k.Method.Algorithm()
+		jwk.Use = "sig"
+		set.Keys = append(set.Keys, *jwk)
+	}
+	return set, nil
+}
+
+// KeyFunc 根据令牌头部的kid返回未过期密钥的验证密钥，
+// 令牌没有kid时返回所有使用相同算法的未过期密钥组成的VerificationKeySet
+func (s *RotatingKeySet) KeyFunc(token *Token) (interface{}, error) {
+	kid, _ := token.Header["kid"].(string)
+	now := TimeFunc()
+
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	if kid == "" {
+		set := new(VerificationKeySet)
+		for _, k := range s.keys {
+			if k.expired(now) || k.Method.Algorithm() != token.Method.Algorithm() {
+				continue
+			}
+			key, err := verificationKey(k.Key)
+			if err != nil {
+				return nil, err
+			}
+			set.Keys = append(set.Keys, key)
+		}
+		if len(set.Keys) == 0 {
+			return nil, ErrJWKNotFound
+		}
+		return set, nil
+	}
+
+	for _, k := range s.keys {
+		if k.KeyID != kid || k.expired(now) {
+			continue
+		}
+		if k.Method.Algorithm() != token.Method.Algorithm() {
+			return nil, ErrJWKNotFound
+		}
+		return verificationKey(k.Key)
+	}
+	return nil, ErrJWKNotFound
+}
+
+// verificationKey 返回签名密钥对应的验证密钥
+func verificationKey(key interface{}) (interface{}, error) {
+	if _, ok := key.([]byte); ok {
+		return key, nil
+	}
+	return PublicKeyOf(key)
+}
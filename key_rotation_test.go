@@ -0,0 +1,109 @@
+package jwt
+
+import (
+	"testing"
+	"time"
+
+	"github.com/gotoxu/assert"
+)
+
+func TestRotatingKeySet(t *testing.T) {
+	defer func() { TimeFunc = time.Now }()
+
+	now := time.Now()
+	TimeFunc = func() time.Time { return now }
+
+	oldKey, _ := GenerateKeyForMethod(ES256)
+	currentKey, _ := GenerateKeyForMethod(ES256)
+	nextKey, _ := GenerateKeyForMethod(RS256)
+	expiredKey, _ := GenerateKeyForMethod(ES256)
+	day := 24 * time.Hour
+
+	set, err := NewRotatingKeySet(
+		RotatingKey{KeyID: "old", Method: ES256, Key: oldKey, ActivateAt: now.Add(-60 * day), RetireAt: now.Add(-30 * day), ExpireAt: now.Add(7 * day)},
+		RotatingKey{KeyID: "next", Method: RS256, Key: nextKey, ActivateAt: now.Add(day)},
+		RotatingKey{Method: ES256, Key: currentKey, ActivateAt: now.Add(-30 * day)},
+		RotatingKey{KeyID: "expired", Method: ES256, Key: expiredKey, ActivateAt: now.Add(-90 * day), ExpireAt: now.Add(-30 * day)},
+	)
+	assert.Nil(t, err)
+
+	active, err := set.Active()
+	assert.Nil(t, err)
+	jwk, _ := NewJSONWebKey(currentKey)
+	thumbprint, _ := jwk.Public().Thumbprint()
+	assert.DeepEqual(t, active.KeyID, thumbprint)
+
+	tokenString, err := set.SignClaims(MapClaims{"sub": "current"})
+	assert.Nil(t, err)
+	token, err := Parse(tokenString, set.KeyFunc)
+	assert.Nil(t, err)
+	assert.DeepEqual(t, token.Header["kid"], thumbprint)
+	assert.DeepEqual(t, token.Header["alg"], "ES256")
+
+	jwks, err := set.JWKS()
+	assert.Nil(t, err)
+	var kids []string
+	for _, k := range jwks.Keys {
+		assert.False(t, k.IsPrivate())
+		assert.DeepEqual(t, k.Use, "sig")
+		kids = append(kids, k.KeyID)
+	}
+	assert.DeepEqual(t, kids, []string{"next", thumbprint, "old"})
+
+	oldToken := NewWithClaims(ES256, MapClaims{})
+	oldToken.Header["kid"] = "old"
+	oldString, _ := oldToken.Generate(oldKey)
+	_, err = Parse(oldString, set.KeyFunc)
+	assert.Nil(t, err)
+
+	expiredToken := NewWithClaims(ES256, MapClaims{})
+	expiredToken.Header["kid"] = "expired"
+	expiredString, _ := expiredToken.Generate(expiredKey)
+	_, err = Parse(expiredString, set.KeyFunc)
+	assert.NotNil(t, err)
+
+	// 下一个密钥激活后用它签名，旧密钥过期后不再接受
+	now = now.Add(8 * day)
+	token = New(HS256Method)
+	tokenString, err = set.Sign(token)
+	assert.Nil(t, err)
+	token, err = Parse(tokenString, set.KeyFunc)
+	assert.Nil(t, err)
+	assert.DeepEqual(t, token.Header["kid"], "next")
+	assert.DeepEqual(t, token.Method, SigningMethod(RS256))
+
+	_, err = Parse(oldString, set.KeyFunc)
+	assert.NotNil(t, err)
+
+	set.Prune()
+	jwks, _ = set.JWKS()
+	assert.DeepEqual(t, len(jwks.Keys), 2)
+
+	set.Remove("next")
+	set.Remove(thumbprint)
+	_, err = set.Active()
+	assert.DeepEqual(t, err, ErrNoActiveKey)
+}
+
+func TestRotatingKeySetAdd(t *testing.T) {
+	key, _ := GenerateKeyForMethod(ES256)
+	secret, _ := GenerateHMACSecret(32)
+
+	set, err := NewRotatingKeySet(RotatingKey{KeyID: "a", Method: ES256, Key: key})
+	assert.Nil(t, err)
+	assert.DeepEqual(t, set.Add(RotatingKey{KeyID: "a", Method: ES256, Key: key}), ErrDuplicateKeyID)
+	assert.DeepEqual(t, set.Add(RotatingKey{KeyID: "b", Method: RS256, Key: key}), ErrRotatingKeyMethod)
+	assert.Nil(t, set.Add(RotatingKey{KeyID: "hmac", Method: HS256Method, Key: secret, ActivateAt: time.Now().Add(-time.Hour)}))
+	// HMAC密钥的指纹会泄露密钥的摘要，不能用作kid
+	assert.DeepEqual(t, set.Add(RotatingKey{Method: HS256Method, Key: secret}), ErrSymmetricKeyID)
+
+	tokenString, err := set.SignClaims(MapClaims{})
+	assert.Nil(t, err)
+	token, err := Parse(tokenString, set.KeyFunc)
+	assert.Nil(t, err)
+	assert.DeepEqual(t, token.Header["kid"], "hmac")
+
+	jwks, _ := set.JWKS()
+	assert.DeepEqual(t, len(jwks.Keys), 1)
+	assert.DeepEqual(t, jwks.Keys[0].KeyID, "a")
+}
@@ -0,0 +1,50 @@
+package jwt
+
+import (
+	"errors"
+	"fmt"
+)
+
+// DefaultMaxKeyAttempts 是Parser.MaxKeyAttempts为0时最多尝试的候选密钥数量
+const DefaultMaxKeyAttempts = 8
+
+// errors
+var (
+	ErrNoCompatibleKey = errors.New("no key in the set is compatible with the signing method")
+	ErrNoKeyMatched    = errors.New("signature does not match any key in the set")
+)
+
+// VerificationKeySet 可以由KeyFunc返回，用于令牌没有kid而需要尝试多个密钥的情况(例如密钥轮换期间)。
+// Parser按Keys中的顺序，只使用与签名算法匹配的密钥验证签名，第一个验证通过的密钥记录在Token.Key中
+type VerificationKeySet struct {
+	Keys []interface{}
+}
+
+// verifyKeySet 依次使用兼容的候选密钥验证签名，返回验证通过的密钥
+func (p *Parser) verifyKeySet(method SigningMethod, signingString, signature string, set *VerificationKeySet) (interface{}, error) {
+	var candidates []interface{}
+	for _, key := range set.Keys {
+		if KeyMatchesMethod(method, key) {
+			candidates = append(candidates, key)
+		}
+	}
+
+	if len(candidates) == 0 {
+		return nil, &ValidationError{Inner: ErrNoCompatibleKey, Errors: ValidationErrorUnverifiable}
+	}
+
+	max := p.MaxKeyAttempts
+	if max <= 0 {
+		max = DefaultMaxKeyAttempts
+	}
+	if len(candidates) > max {
+		return nil, NewValidationError(fmt.Sprintf("%d candidate keys exceed the maximum of %d attempts", len(candidates), max), ValidationErrorUnverifiable)
+	}
+
+	for _, key := range candidates {
+		if err := p.verify(method, signingString, signature, key); err == nil {
+			return key, nil
+		}
+	}
+	return nil, &ValidationError{Inner: ErrNoKeyMatched, Errors: ValidationErrorSignatureInvalid}
+}
@@ -0,0 +1,103 @@
+package jwt
+
+import (
+	"testing"
+	"time"
+
+	"github.com/gotoxu/assert"
+)
+
+func TestVerificationKeySet(t *testing.T) {
+	keyA := []byte("key-a-this-is-a-32-byte-test-key")
+	keyB := []byte("key-b-this-is-a-32-byte-test-key")
+	keyC := []byte("key-c-this-is-a-32-byte-test-key")
+	rsaKey := loadRSAPublicKeyFromDisk("test/sample_key.pub")
+
+	tokenString, err := NewWithClaims(HS256Method, MapClaims{"sub": "alice"}).Generate(keyB)
+	assert.Nil(t, err)
+
+	var tests = []struct {
+		name     string
+		keys     []interface{}
+		attempts int
+		errors   uint32
+		inner    error
+		matched  interface{}
+	}{
+		{"second key matches", []interface{}{keyA, rsaKey, keyB}, 0, 0, nil, keyB},
+		{"incompatible keys skipped", []interface{}{rsaKey, rsaKey, rsaKey, keyB}, 1, 0, nil, keyB},
+		{"no compatible key", []interface{}{rsaKey}, 0, ValidationErrorUnverifiable, ErrNoCompatibleKey, nil},
+		{"no key matches", []interface{}{keyA, keyC}, 0, ValidationErrorSignatureInvalid, ErrNoKeyMatched, nil},
+		{"too many keys", []interface{}{keyA, keyC, keyB}, 2, ValidationErrorUnverifiable, nil, nil},
+	}
+
+	for _, test := range tests {
+		keys := test.keys
+		p := &Parser{MaxKeyAttempts: test.attempts}
+		token, err := p.Parse(tokenString, func(*Token) (interface{}, error) {
+			return &VerificationKeySet{Keys: keys}, nil
+		})
+
+		if test.errors == 0 {
+			assert.Nil(t, err, test.name)
+			assert.True(t, token.Valid, test.name)
+			assert.DeepEqual(t, token.Key, test.matched, test.name)
+			continue
+		}
+
+		assert.NotNil(t, err, test.name)
+		assert.False(t, token.Valid, test.name)
+		assert.Nil(t, token.Key, test.name)
+		assert.DeepEqual(t, err.(*ValidationError).Errors, test.errors, test.name)
+		if test.inner != nil {
+			assert.DeepEqual(t, err.(*ValidationError).Inner, test.inner, test.name)
+		}
+	}
+
+	// 没有Inner的ValidationError合并后仍然保留错误信息
+	p := &Parser{MaxKeyAttempts: 2}
+	_, err = p.Parse(tokenString, func(*Token) (interface{}, error) {
+		return &VerificationKeySet{Keys: []interface{}{keyA, keyC, keyB}}, nil
+	})
+	assert.DeepEqual(t, err.Error(), "3 candidate keys exceed the maximum of 2 attempts")
+
+	// claims验证的错误不会被签名验证的错误覆盖
+	expired, err := NewWithClaims(HS256Method, MapClaims{"exp": time.Now().Add(-time.Hour).Unix()}).Generate(keyB)
+	assert.Nil(t, err)
+	_, err = p.Parse(expired, func(*Token) (interface{}, error) {
+		return &VerificationKeySet{Keys: []interface{}{keyA, keyC, keyB}}, nil
+	})
+	assert.DeepEqual(t, err.(*ValidationError).Errors, ValidationErrorExpired|ValidationErrorUnverifiable)
+	assert.StringContains(t, err.Error(), "expired")
+}
+
+func TestRotatingKeySetWithoutKeyID(t *testing.T) {
+	now := time.Now()
+	day := 24 * time.Hour
+	oldKey, _ := GenerateKeyForMethod(ES256)
+	currentKey, _ := GenerateKeyForMethod(ES256)
+
+	set, err := NewRotatingKeySet(
+		RotatingKey{KeyID: "old", Method: ES256, Key: oldKey, ActivateAt: now.Add(-2 * day), RetireAt: now.Add(-day), ExpireAt: now.Add(day)},
+		RotatingKey{KeyID: "current", Method: ES256, Key: currentKey, ActivateAt: now.Add(-day)},
+	)
+	assert.Nil(t, err)
+
+	// 旧令牌没有kid，需要尝试所有未过期的ES256密钥
+	tokenString, err := NewWithClaims(ES256, MapClaims{}).Generate(oldKey)
+	assert.Nil(t, err)
+
+	token, err := Parse(tokenString, set.KeyFunc)
+	assert.Nil(t, err)
+	oldPublic, _ := PublicKeyOf(oldKey)
+	assert.DeepEqual(t, token.Key, oldPublic)
+
+	jwks, err := set.JWKS()
+	assert.Nil(t, err)
+	for i := range jwks.Keys {
+		jwks.Keys[i].KeyID = ""
+	}
+	token, err = Parse(tokenString, func(token *Token) (interface{}, error) { return jwks.selectKey(token) })
+	assert.Nil(t, err)
+	assert.DeepEqual(t, token.Key, oldPublic)
+}
@@ -0,0 +1,166 @@
+package jwt
+
+import (
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/rsa"
+	"crypto/x509"
+	"encoding/pem"
+	"errors"
+)
+
+// errors
+var (
+	ErrNoPrivateKeyInPEM = errors.New("PEM data does not contain a supported private key")
+	ErrNoPublicKeyInPEM  = errors.New("PEM data does not contain a supported public key or certificate")
+)
+
+// ParsePrivateKeyFromPEM 解析PEM编码的私钥，支持PKCS1、PKCS8和SEC1格式的RSA、ECDSA和Ed25519私钥。
+// data可以包含多个PEM块(例如EC PARAMETERS或证书链)，返回其中第一个私钥及其可用的签名算法
+func ParsePrivateKeyFromPEM(data []byte) (crypto.PrivateKey, []string, error) {
+	return ParsePrivateKeyFromPEMWithPassword(data, nil)
+}
+
+// ParsePrivateKeyFromPEMWithPassword 与ParsePrivateKeyFromPEM相同，但可以解密PBES2加密的PKCS8私钥("ENCRYPTED PRIVATE KEY")。
+// password为nil时遇到加密私钥返回ErrEncryptedPrivateKey
+func ParsePrivateKeyFromPEMWithPassword(data []byte, password []byte) (crypto.PrivateKey, []string, error) {
+	var block *pem.Block
+	found := false
+
+	for {
+		if block, data = pem.Decode(data); block == nil {
+			break
+		}
+		found = true
+
+		var key crypto.PrivateKey
+		var err error
+		if block.Type == "ENCRYPTED PRIVATE KEY" {
+			if password == nil {
+				return nil, nil, ErrEncryptedPrivateKey
+			}
+			key, err = DecryptPKCS8PrivateKey(block.Bytes, password)
+		} else {
+			key, err = parsePrivateKeyBlock(block)
+		}
+		if err != nil {
+			return nil, nil, err
+		}
+		if key != nil {
+			return key, KeyAlgorithms(key), nil
+		}
+	}
+
+	if !found {
+		return nil, nil, ErrKeyMustBePEMEncoded
+	}
+	return nil, nil, ErrNoPrivateKeyInPEM
+}
+
+// ParsePublicKeyFromPEM 解析PEM编码的公钥，支持PKIX、PKCS1公钥和X.509证书。
+// data可以包含多个PEM块，返回其中第一个公钥或证书中的公钥；
+// 如果只包含私钥，则返回私钥对应的公钥
+func ParsePublicKeyFromPEM(data []byte) (crypto.PublicKey, []string, error) {
+	var block *pem.Block
+	var fallback crypto.PublicKey
+	rest := data
+
+	for {
+		if block, rest = pem.Decode(rest); block == nil {
+			break
+		}
+
+		var pub crypto.PublicKey
+		var err error
+		switch block.Type {
+		case "PUBLIC KEY":
+			pub, err = x509.ParsePKIXPublicKey(block.Bytes)
+		case "RSA PUBLIC KEY":
+			pub, err = x509.ParsePKCS1PublicKey(block.Bytes)
+		case "CERTIFICATE":
+			var cert *x509.Certificate
+			if cert, err = x509.ParseCertificate(block.Bytes); err == nil {
+				pub = cert.PublicKey
+			}
+		default:
+			if fallback == nil {
+				if key, err := parsePrivateKeyBlock(block); err == nil && key != nil {
+					if pub, err := PublicKeyOf(key); err == nil {
+						fallback = pub
+					}
+				}
+			}
+			continue
+		}
+
+		if err != nil {
+			return nil, nil, err
+		}
+		if algs := KeyAlgorithms(pub); len(algs) > 0 {
+			return pub, algs, nil
+		}
+	}
+
+	if fallback != nil {
+		return fallback, KeyAlgorithms(fallback), nil
+	}
+	if block, _ = pem.Decode(data); block == nil {
+		return nil, nil, ErrKeyMustBePEMEncoded
+	}
+	return nil, nil, ErrNoPublicKeyInPEM
+}
+
+// parsePrivateKeyBlock 解析单个PEM块中的私钥，块中不是私钥时返回nil, nil。
+// 出错时总是返回无类型的nil，避免带类型的nil指针被当作有效的密钥
+func parsePrivateKeyBlock(block *pem.Block) (crypto.PrivateKey, error) {
+	var key crypto.PrivateKey
+	var err error
+	switch block.Type {
+	case "RSA PRIVATE KEY":
+		var k *rsa.PrivateKey
+		if k, err = x509.ParsePKCS1PrivateKey(block.Bytes); err == nil {
+			key = k
+		}
+	case "EC PRIVATE KEY":
+		var k *ecdsa.PrivateKey
+		if k, err = x509.ParseECPrivateKey(block.Bytes); err == nil {
+			key = k
+		}
+	case "PRIVATE KEY":
+		key, err = x509.ParsePKCS8PrivateKey(block.Bytes)
+	}
+	if err != nil {
+		return nil, err
+	}
+	return key, nil
+}
+
+// KeyAlgorithms 返回可以使用该密钥的签名算法，密钥类型不受支持时返回nil
+func KeyAlgorithms(key interface{}) []string {
+	var curveBits int
+	switch k := key.(type) {
+	case *rsa.PrivateKey, *rsa.PublicKey:
+		return []string{"RS256", "RS384", "RS512", "PS256", "PS384", "PS512"}
+	case *ecdsa.PrivateKey:
+		curveBits = k.Params().BitSize
+	case *ecdsa.PublicKey:
+		curveBits = k.Params().BitSize
+	case ed25519.PrivateKey, ed25519.PublicKey:
+		return []string{"EdDSA"}
+	case []byte:
+		return []string{"HS256", "HS384", "HS512"}
+	default:
+		return nil
+	}
+
+	switch curveBits {
+	case 256:
+		return []string{"ES256"}
+	case 384:
+		return []string{"ES384"}
+	case 521:
+		return []string{"ES512"}
+	}
+	return nil
+}
@@ -0,0 +1,156 @@
+package jwt
+
+import (
+	"bytes"
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"io/ioutil"
+	"math/big"
+	"testing"
+	"time"
+
+	"github.com/gotoxu/assert"
+)
+
+func TestParsePrivateKeyFromPEM(t *testing.T) {
+	rsaKey := loadRSAPrivateKeyFromDisk("test/sample_key")
+	ecKey, _ := GenerateKeyForMethod(ES384)
+	edKey, _ := GenerateEd25519Key()
+
+	pkcs1, _ := MarshalPrivateKeyToPEM(rsaKey, PKCS1)
+	rsaPKCS8, _ := MarshalPrivateKeyToPEM(rsaKey, PKCS8)
+	sec1, _ := MarshalPrivateKeyToPEM(ecKey, SEC1)
+	ecPKCS8, _ := MarshalPrivateKeyToPEM(ecKey, PKCS8)
+	edPKCS8, _ := MarshalPrivateKeyToPEM(edKey, PKCS8)
+	rsaPub, _ := MarshalPublicKeyToPEM(rsaKey, PKIX)
+	params := pem.EncodeToMemory(&pem.Block{Type: "EC PARAMETERS", Bytes: []byte{0x06, 0x05, 0x2b, 0x81, 0x04, 0x00, 0x22}})
+
+	var tests = []struct {
+		name string
+		data []byte
+		key  interface{}
+		algs []string
+		err  error
+	}{
+		{"pkcs1", pkcs1, rsaKey, []string{"RS256", "RS384", "RS512", "PS256", "PS384", "PS512"}, nil},
+		{"rsa pkcs8", rsaPKCS8, rsaKey, []string{"RS256", "RS384", "RS512", "PS256", "PS384", "PS512"}, nil},
+		{"sec1", sec1, ecKey, []string{"ES384"}, nil},
+		{"ec pkcs8", ecPKCS8, ecKey, []string{"ES384"}, nil},
+		{"ed25519", edPKCS8, edKey, []string{"EdDSA"}, nil},
+		{"ec parameters bundle", append(params, sec1...), ecKey, []string{"ES384"}, nil},
+		{"public key first", append(rsaPub, pkcs1...), rsaKey, []string{"RS256", "RS384", "RS512", "PS256", "PS384", "PS512"}, nil},
+		{"public key only", rsaPub, nil, nil, ErrNoPrivateKeyInPEM},
+		{"not pem", []byte("not a key"), nil, nil, ErrKeyMustBePEMEncoded},
+	}
+
+	for _, test := range tests {
+		key, algs, err := ParsePrivateKeyFromPEM(test.data)
+		assert.DeepEqual(t, err, test.err, test.name)
+		if test.err != nil {
+			continue
+		}
+		assert.DeepEqual(t, key, test.key, test.name)
+		assert.DeepEqual(t, algs, test.algs, test.name)
+	}
+}
+
+func TestParsePublicKeyFromPEM(t *testing.T) {
+	rsaKey := loadRSAPrivateKeyFromDisk("test/sample_key")
+	edKey, _ := GenerateEd25519Key()
+
+	ecPub, err := ioutil.ReadFile("test/ec512-public.pem")
+	assert.Nil(t, err)
+	ecKey, err := ParseECPublicKeyFromPEM(ecPub)
+	assert.Nil(t, err)
+
+	pkixPEM, _ := MarshalPublicKeyToPEM(rsaKey, PKIX)
+	pkcs1, _ := MarshalPublicKeyToPEM(rsaKey, PKCS1)
+	edPub, _ := MarshalPublicKeyToPEM(edKey, PKIX)
+	edPriv, _ := MarshalPrivateKeyToPEM(edKey, PKCS8)
+	cert := selfSignedCertPEM(t, rsaKey)
+	chain := append(append([]byte{}, cert...), pkixPEM...)
+
+	var tests = []struct {
+		name string
+		data []byte
+		key  interface{}
+		algs []string
+		err  error
+	}{
+		{"pkix", pkixPEM, &rsaKey.PublicKey, []string{"RS256", "RS384", "RS512", "PS256", "PS384", "PS512"}, nil},
+		{"pkcs1", pkcs1, &rsaKey.PublicKey, []string{"RS256", "RS384", "RS512", "PS256", "PS384", "PS512"}, nil},
+		{"ec", ecPub, ecKey, []string{"ES512"}, nil},
+		{"ed25519", edPub, edKey.Public(), []string{"EdDSA"}, nil},
+		{"certificate", cert, &rsaKey.PublicKey, []string{"RS256", "RS384", "RS512", "PS256", "PS384", "PS512"}, nil},
+		{"certificate chain", chain, &rsaKey.PublicKey, []string{"RS256", "RS384", "RS512", "PS256", "PS384", "PS512"}, nil},
+		{"private key", edPriv, edKey.Public(), []string{"EdDSA"}, nil},
+		{"not pem", []byte("not a key"), nil, nil, ErrKeyMustBePEMEncoded},
+		{"unknown block", pem.EncodeToMemory(&pem.Block{Type: "FOO"}), nil, nil, ErrNoPublicKeyInPEM},
+	}
+
+	for _, test := range tests {
+		key, algs, err := ParsePublicKeyFromPEM(test.data)
+		assert.DeepEqual(t, err, test.err, test.name)
+		if test.err != nil {
+			continue
+		}
+		assert.DeepEqual(t, key, test.key, test.name)
+		assert.DeepEqual(t, algs, test.algs, test.name)
+	}
+}
+
+func TestParseCorruptKeyBlocks(t *testing.T) {
+	for _, typ := range []string{"RSA PRIVATE KEY", "EC PRIVATE KEY", "PRIVATE KEY"} {
+		data := pem.EncodeToMemory(&pem.Block{Type: typ, Bytes: []byte{0x30, 0x03, 0x02, 0x01}})
+
+		key, algs, err := ParsePrivateKeyFromPEM(data)
+		assert.NotNil(t, err, typ)
+		assert.True(t, key == nil, typ)
+		assert.Len(t, algs, 0, typ)
+
+		// 损坏的私钥块不能作为公钥的来源，也不能导致PublicKeyOf处理带类型的nil
+		pub, _, err := ParsePublicKeyFromPEM(data)
+		assert.DeepEqual(t, err, ErrNoPublicKeyInPEM, typ)
+		assert.True(t, pub == nil, typ)
+	}
+}
+
+func TestParseECPrivateKeyFromPEMPKCS8(t *testing.T) {
+	key, _ := GenerateKeyForMethod(ES256)
+	data, _ := MarshalPrivateKeyToPEM(key, PKCS8)
+
+	parsed, err := ParseECPrivateKeyFromPEM(data)
+	assert.Nil(t, err)
+	assert.True(t, parsed.Equal(key.(*ecdsa.PrivateKey)))
+
+	edKey, _ := GenerateEd25519Key()
+	data, _ = MarshalPrivateKeyToPEM(edKey, PKCS8)
+	_, err = ParseECPrivateKeyFromPEM(data)
+	assert.DeepEqual(t, err, ErrNotECPrivateKey)
+}
+
+func TestKeyAlgorithms(t *testing.T) {
+	assert.DeepEqual(t, KeyAlgorithms([]byte("secret")), []string{"HS256", "HS384", "HS512"})
+	assert.DeepEqual(t, KeyAlgorithms(ed25519.PublicKey(make([]byte, ed25519.PublicKeySize))), []string{"EdDSA"})
+	assert.DeepEqual(t, len(KeyAlgorithms("secret")), 0)
+}
+
+func selfSignedCertPEM(t *testing.T, key *rsa.PrivateKey) []byte {
+	template := &x509.Certificate{
+		SerialNumber: big.NewInt(1),
+		Subject:      pkix.Name{CommonName: "jwt test"},
+		NotBefore:    time.Now().Add(-time.Hour),
+		NotAfter:     time.Now().Add(time.Hour),
+	}
+	der, err := x509.CreateCertificate(rand.Reader, template, template, &key.PublicKey, key)
+	assert.Nil(t, err)
+
+	var buf bytes.Buffer
+	pem.Encode(&buf, &pem.Block{Type: "CERTIFICATE", Bytes: der})
+	return buf.Bytes()
+}
@@ -0,0 +1,91 @@
+package jwt
+
+import (
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/rsa"
+	"errors"
+)
+
+// DefaultRSAKeyBits 是生成RSA密钥时的默认长度
+const DefaultRSAKeyBits = 2048
+
+// errors
+var (
+	ErrUnsupportedKey = errors.New("key type is not supported")
+)
+
+// GenerateRSAKey 生成指定长度的RSA私钥，bits为0时使用DefaultRSAKeyBits
+func GenerateRSAKey(bits int) (*rsa.PrivateKey, error) {
+	if bits == 0 {
+		bits = DefaultRSAKeyBits
+	}
+	return rsa.GenerateKey(rand.Reader, bits)
+}
+
+// GenerateECKey 在指定曲线(P-256、P-384或P-521)上生成ECDSA私钥
+func GenerateECKey(curve elliptic.Curve) (*ecdsa.PrivateKey, error) {
+	if name, _ := curveName(curve); name == "" {
+		return nil, ErrJWKUnsupportedCurve
+	}
+	return ecdsa.GenerateKey(curve, rand.Reader)
+}
+
+// GenerateEd25519Key 生成Ed25519私钥
+func GenerateEd25519Key() (ed25519.PrivateKey, error) {
+	_, key, err := ed25519.GenerateKey(rand.Reader)
+	return key, err
+}
+
+// GenerateHMACSecret 生成指定字节数的随机HMAC密钥
+func GenerateHMACSecret(size int) ([]byte, error) {
+	if size <= 0 {
+		return nil, ErrInvalidKey
+	}
+	secret := make([]byte, size)
+	if _, err := rand.Read(secret); err != nil {
+		return nil, err
+	}
+	return secret, nil
+}
+
+// GenerateKeyForMethod 为签名方法生成合适的签名密钥：
+// HMAC生成与哈希输出等长的密钥，RSA生成DefaultRSAKeyBits位的密钥，ECDSA使用算法对应的曲线
+func GenerateKeyForMethod(method SigningMethod) (interface{}, error) {
+	switch m := method.(type) {
+	case *HMACMethod:
+		return GenerateHMACSecret(m.Hash.Size())
+	case *RSAMethod, *RSAPSSMethod:
+		return GenerateRSAKey(DefaultRSAKeyBits)
+	case *ECDSAMethod:
+		switch m.CurveBits {
+		case 256:
+			return GenerateECKey(elliptic.P256())
+		case 384:
+			return GenerateECKey(elliptic.P384())
+		case 521:
+			return GenerateECKey(elliptic.P521())
+		}
+	case *EdDSAMethod:
+		return GenerateEd25519Key()
+	}
+	return nil, ErrUnsupportedKey
+}
+
+// PublicKeyOf 返回私钥对应的公钥，传入公钥时原样返回
+func PublicKeyOf(key interface{}) (crypto.PublicKey, error) {
+	switch k := key.(type) {
+	case *rsa.PrivateKey:
+		return &k.PublicKey, nil
+	case *ecdsa.PrivateKey:
+		return &k.PublicKey, nil
+	case ed25519.PrivateKey:
+		return k.Public(), nil
+	case *rsa.PublicKey, *ecdsa.PublicKey, ed25519.PublicKey:
+		return k, nil
+	}
+	return nil, ErrUnsupportedKey
+}
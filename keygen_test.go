@@ -0,0 +1,109 @@
+package jwt
+
+import (
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/rsa"
+	"crypto/x509"
+	"encoding/pem"
+	"testing"
+
+	"github.com/gotoxu/assert"
+)
+
+func TestGenerateKeyForMethod(t *testing.T) {
+	for _, alg := range []string{"HS256", "HS512", "RS256", "PS384", "ES256", "ES384", "ES512", "EdDSA"} {
+		method := GetSigningMethod(alg)
+		key, err := GenerateKeyForMethod(method)
+		assert.Nil(t, err, alg)
+
+		verifyKey := key
+		if _, ok := method.(*HMACMethod); !ok {
+			verifyKey, err = PublicKeyOf(key)
+			assert.Nil(t, err, alg)
+		}
+
+		tokenString, err := New(method).Generate(key)
+		assert.Nil(t, err, alg)
+
+		_, err = Parse(tokenString, func(*Token) (interface{}, error) { return verifyKey, nil })
+		assert.Nil(t, err, alg)
+	}
+}
+
+func TestMarshalPEM(t *testing.T) {
+	rsaKey := loadRSAPrivateKeyFromDisk("test/sample_key")
+	ecKey, _ := GenerateKeyForMethod(ES256)
+	edKey, _ := GenerateEd25519Key()
+
+	var tests = []struct {
+		key       interface{}
+		format    PEMFormat
+		blockType string
+		valid     bool
+	}{
+		{rsaKey, PKCS1, "RSA PRIVATE KEY", true},
+		{rsaKey, PKCS8, "PRIVATE KEY", true},
+		{rsaKey, SEC1, "", false},
+		{ecKey, SEC1, "EC PRIVATE KEY", true},
+		{ecKey, PKCS8, "PRIVATE KEY", true},
+		{ecKey, PKCS1, "", false},
+		{edKey, PKCS8, "PRIVATE KEY", true},
+		{edKey, SEC1, "", false},
+	}
+
+	for _, test := range tests {
+		data, err := MarshalPrivateKeyToPEM(test.key, test.format)
+		if !test.valid {
+			assert.NotNil(t, err)
+			continue
+		}
+		assert.Nil(t, err)
+
+		block, _ := pem.Decode(data)
+		assert.DeepEqual(t, block.Type, test.blockType)
+
+		data, err = MarshalPublicKeyToPEM(test.key, PKIX)
+		assert.Nil(t, err)
+		block, _ = pem.Decode(data)
+		pub, err := x509.ParsePKIXPublicKey(block.Bytes)
+		assert.Nil(t, err)
+
+		switch k := test.key.(type) {
+		case *rsa.PrivateKey:
+			assert.DeepEqual(t, pub, &k.PublicKey)
+		case *ecdsa.PrivateKey:
+			assert.True(t, k.PublicKey.Equal(pub))
+		case ed25519.PrivateKey:
+			assert.DeepEqual(t, pub, k.Public())
+		}
+	}
+
+	data, err := MarshalPublicKeyToPEM(rsaKey, PKCS1)
+	assert.Nil(t, err)
+	block, _ := pem.Decode(data)
+	assert.DeepEqual(t, block.Type, "RSA PUBLIC KEY")
+	pub, err := x509.ParsePKCS1PublicKey(block.Bytes)
+	assert.Nil(t, err)
+	assert.DeepEqual(t, pub, &rsaKey.PublicKey)
+}
+
+func TestJWKRoundTrip(t *testing.T) {
+	for _, alg := range []string{"HS256", "RS256", "ES256", "ES384", "ES512", "EdDSA"} {
+		key, _ := GenerateKeyForMethod(GetSigningMethod(alg))
+
+		jwk, err := NewJSONWebKey(key)
+		assert.Nil(t, err, alg)
+		parsed, err := jwk.Key()
+		assert.Nil(t, err, alg)
+		assert.DeepEqual(t, parsed, key, alg)
+
+		if public := jwk.Public(); public != nil {
+			assert.False(t, public.IsPrivate(), alg)
+			pub, _ := PublicKeyOf(key)
+			parsed, err = public.Key()
+			assert.Nil(t, err, alg)
+			assert.DeepEqual(t, parsed, pub, alg)
+		}
+	}
+}
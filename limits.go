@@ -0,0 +1,148 @@
+package jwt
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+)
+
+// Limits 的默认值
+const (
+	DefaultMaxTokenSize     = 16 << 10
+	DefaultMaxSegmentSize   = 8 << 10
+	DefaultMaxJSONDepth     = 16
+	DefaultMaxHeaderMembers = 32
+	DefaultMaxClaims        = 256
+)
+
+// Limits 限制Parser接受的令牌大小和结构，用于解析不受信任的输入。
+// 为0的字段表示不限制
+type Limits struct {
+	// MaxTokenSize 是令牌字符串的最大长度
+	MaxTokenSize int
+	// MaxSegmentSize 是头部、载荷和签名每一段编码后的最大长度
+	MaxSegmentSize int
+	// MaxJSONDepth 是头部和载荷中JSON对象和数组的最大嵌套深度，顶层对象的深度为1
+	MaxJSONDepth int
+	// MaxHeaderMembers 是头部参数的最大数量
+	MaxHeaderMembers int
+	// MaxClaims 是载荷中顶层claim的最大数量
+	MaxClaims int
+}
+
+// DefaultLimits 返回适合解析Authorization头部中令牌的默认限制
+func DefaultLimits() *Limits {
+	return &Limits{
+		MaxTokenSize:     DefaultMaxTokenSize,
+		MaxSegmentSize:   DefaultMaxSegmentSize,
+		MaxJSONDepth:     DefaultMaxJSONDepth,
+		MaxHeaderMembers: DefaultMaxHeaderMembers,
+		MaxClaims:        DefaultMaxClaims,
+	}
+}
+
+// checkToken 在分割和解码之前检查令牌字符串的长度和各段长度
+func (l *Limits) checkToken(tokenString string) error {
+	if l.MaxTokenSize > 0 && len(tokenString) > l.MaxTokenSize {
+		return NewValidationError(fmt.Sprintf("token exceeds the maximum size of %d bytes", l.MaxTokenSize), ValidationErrorMalformed)
+	}
+	if strings.Count(tokenString, ".") != 2 {
+		return NewValidationError("token contains an invalid number of segments", ValidationErrorMalformed)
+	}
+
+	if l.MaxSegmentSize > 0 {
+		for i, seg := range strings.SplitN(tokenString, ".", 3) {
+			if len(seg) > l.MaxSegmentSize {
+				return NewValidationError(fmt.Sprintf("token segment %d exceeds the maximum size of %d bytes", i, l.MaxSegmentSize), ValidationErrorMalformed)
+			}
+		}
+	}
+	return nil
+}
+
+// jsonScanner 使用json.Decoder的Token流检查JSON的结构，不构建任何值。
+// strict为true时还要求输入是单个JSON对象，并拒绝重复的成员名。
+// encoding/json按不区分大小写的方式匹配结构体字段，所以成员名比较前先做大小写折叠
+type jsonScanner struct {
+	maxDepth   int
+	maxMembers int
+	strict     bool
+}
+
+type jsonFrame struct {
+	object    bool
+	expectKey bool
+	keys      map[string]bool
+}
+
+func (s *jsonScanner) scan(data []byte) error {
+	dec := json.NewDecoder(bytes.NewReader(data))
+	dec.UseNumber()
+
+	var stack []jsonFrame
+	members := 0
+	done := false
+	for {
+		tok, err := dec.Token()
+		if err == io.EOF {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+
+		if s.strict {
+			if done {
+				return errors.New("unexpected data after top-level JSON object")
+			}
+			if len(stack) == 0 && tok != json.Delim('{') {
+				return errors.New("JSON value must be an object")
+			}
+		}
+
+		if n := len(stack); n > 0 && stack[n-1].expectKey {
+			if _, ok := tok.(json.Delim); !ok {
+				stack[n-1].expectKey = false
+				if s.strict {
+					key := tok.(string)
+					// 先转大写再转小写，使ſ和K(开尔文符号)与encoding/json一样折叠为s和k
+					folded := strings.ToLower(strings.ToUpper(key))
+					if stack[n-1].keys[folded] {
+						return fmt.Errorf("duplicate JSON member %q", key)
+					}
+					stack[n-1].keys[folded] = true
+				}
+				if n == 1 {
+					if members++; s.maxMembers > 0 && members > s.maxMembers {
+						return fmt.Errorf("JSON object has more than %d members", s.maxMembers)
+					}
+				}
+				continue
+			}
+		}
+
+		switch tok {
+		case json.Delim('{'), json.Delim('['):
+			frame := jsonFrame{object: tok == json.Delim('{'), expectKey: tok == json.Delim('{')}
+			if s.strict && frame.object {
+				frame.keys = make(map[string]bool)
+			}
+			stack = append(stack, frame)
+			if s.maxDepth > 0 && len(stack) > s.maxDepth {
+				return fmt.Errorf("JSON nesting exceeds the maximum depth of %d", s.maxDepth)
+			}
+			continue
+		case json.Delim('}'), json.Delim(']'):
+			stack = stack[:len(stack)-1]
+			done = len(stack) == 0
+		}
+
+		// 一个值结束后，所在的对象接下来应该是键
+		if n := len(stack); n > 0 && stack[n-1].object {
+			stack[n-1].expectKey = true
+		}
+	}
+}
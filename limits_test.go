@@ -0,0 +1,91 @@
+package jwt
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/gotoxu/assert"
+)
+
+func TestParserLimits(t *testing.T) {
+	key := []byte("this-is-a-32-byte-hs256-test-key")
+	keyFunc := func(*Token) (interface{}, error) { return key, nil }
+
+	sign := func(header, claims map[string]interface{}) string {
+		token := NewWithClaims(HS256Method, MapClaims(claims))
+		for k, v := range header {
+			token.Header[k] = v
+		}
+		s, err := token.Generate(key)
+		assert.Nil(t, err)
+		return s
+	}
+
+	nested := func(depth int) interface{} {
+		var v interface{} = "leaf"
+		for i := 0; i < depth; i++ {
+			if i%2 == 0 {
+				v = []interface{}{v}
+			} else {
+				v = map[string]interface{}{"n": v}
+			}
+		}
+		return v
+	}
+
+	manyClaims := map[string]interface{}{}
+	for i := 0; i < 10; i++ {
+		manyClaims[fmt.Sprintf("c%d", i)] = map[string]interface{}{"a": 1, "b": 2}
+	}
+
+	limits := &Limits{MaxTokenSize: 600, MaxSegmentSize: 300, MaxJSONDepth: 4, MaxHeaderMembers: 3, MaxClaims: 10}
+
+	var tests = []struct {
+		name  string
+		token string
+		valid bool
+	}{
+		{"within limits", sign(nil, manyClaims), true},
+		{"token too large", sign(nil, map[string]interface{}{"a": strings.Repeat("x", 200), "b": strings.Repeat("x", 200)}), false},
+		{"segment too large", sign(nil, map[string]interface{}{"a": strings.Repeat("x", 250)}), false},
+		{"nesting at limit", sign(nil, map[string]interface{}{"n": nested(3)}), true},
+		{"nesting too deep", sign(nil, map[string]interface{}{"n": nested(4)}), false},
+		{"too many claims", sign(nil, map[string]interface{}{"a": 1, "b": 1, "c": 1, "d": 1, "e": 1, "f": 1, "g": 1, "h": 1, "i": 1, "j": 1, "k": 1}), false},
+		{"too many header members", sign(map[string]interface{}{"kid": "a", "cty": "b"}, nil), false},
+		{"too many segments", "a.b.c.d", false},
+		{"not json", EncodeSegment([]byte(`{"alg":"HS256"}`)) + "." + EncodeSegment([]byte(`{"a":`)) + ".sig", false},
+	}
+
+	for _, test := range tests {
+		_, err := (&Parser{Limits: limits}).Parse(test.token, keyFunc)
+		if test.valid {
+			assert.Nil(t, err, test.name)
+			continue
+		}
+		assert.NotNil(t, err, test.name)
+		assert.DeepEqual(t, err.(*ValidationError).Errors, ValidationErrorMalformed, test.name)
+
+		// 未设置Limits时行为不变
+		if !strings.HasPrefix(test.name, "too many segments") && !strings.HasPrefix(test.name, "not json") {
+			_, err = new(Parser).Parse(test.token, keyFunc)
+			assert.Nil(t, err, test.name)
+		}
+	}
+
+	// 签名段同样受MaxSegmentSize限制，即使没有设置MaxTokenSize
+	oversized := sign(nil, nil) + strings.Repeat("A", 400)
+	_, err := (&Parser{Limits: &Limits{MaxSegmentSize: 300}}).Parse(oversized, keyFunc)
+	assert.NotNil(t, err)
+	assert.DeepEqual(t, err.(*ValidationError).Errors, ValidationErrorMalformed)
+	assert.StringContains(t, err.Error(), "segment 2")
+}
+
+func TestDefaultLimits(t *testing.T) {
+	tokenString := makeSampleToken(MapClaims{"foo": "bar"}, loadRSAPrivateKeyFromDisk("test/sample_key"))
+	_, err := (&Parser{Limits: DefaultLimits()}).Parse(tokenString, defaultKeyFunc)
+	assert.Nil(t, err)
+
+	_, err = (&Parser{Limits: DefaultLimits()}).Parse(strings.Repeat("a", DefaultMaxTokenSize+1), defaultKeyFunc)
+	assert.DeepEqual(t, err.(*ValidationError).Errors, ValidationErrorMalformed)
+}
@@ -0,0 +1,161 @@
+package jwt
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+)
+
+// errors
+var (
+	ErrNoBearerToken = errors.New("request has no bearer token")
+)
+
+type contextKey int
+
+const (
+	tokenContextKey contextKey = iota
+	accessTokenClaimsContextKey
+)
+
+// Middleware 是验证Bearer访问令牌的HTTP中间件，
+// 验证通过后将Token和AccessTokenClaims放入请求的Context中
+type Middleware struct {
+	KeyFunc KeyFunc
+	// KeyFuncContext 不为空时代替KeyFunc，ctx为请求的Context
+	KeyFuncContext KeyFuncContext
+	// Validator 定义了访问令牌的验证规则，不能为空
+	Validator *AccessTokenValidator
+	// ErrorHandler 处理验证失败的请求，为空时返回符合RFC 6750的401或403响应
+	ErrorHandler func(w http.ResponseWriter, r *http.Request, err error)
+	// Audit 不为空时记录被拒绝的令牌，此时不要再把它注册为全局Hook，否则同一令牌会被记录两次
+	Audit *AuditLogger
+}
+
+// Handler 返回包装了next的http.Handler
+func (m *Middleware) Handler(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		tokenString, err := BearerToken(r)
+		if err != nil {
+			m.handleError(w, r, err)
+			return
+		}
+
+		p := m.Validator.Parser
+		if p == nil {
+			p = new(Parser)
+		}
+
+		// 请求的来源信息随ctx传递给Hook和审计日志，没有使用者时不必构造
+		ctx := r.Context()
+		if m.Audit != nil || len(p.hooks()) > 0 {
+			ctx = WithAuditSource(ctx, AuditSourceFromRequest(r))
+		}
+
+		keyFunc := m.KeyFuncContext
+		if keyFunc == nil {
+			keyFunc = ContextKeyFunc(m.KeyFunc)
+		}
+
+		token, err := p.ParseWithClaimsContext(ctx, tokenString, &AccessTokenClaims{}, keyFunc)
+		if err != nil {
+			m.audit(ctx, tokenString, err)
+			m.handleError(w, r, err)
+			return
+		}
+
+		claims, err := m.Validator.Validate(token)
+		if err != nil {
+			m.audit(ctx, tokenString, err)
+			m.handleError(w, r, err)
+			return
+		}
+
+		ctx = context.WithValue(ctx, tokenContextKey, token)
+		ctx = context.WithValue(ctx, accessTokenClaimsContextKey, claims)
+		next.ServeHTTP(w, r.WithContext(ctx))
+	})
+}
+
+func (m *Middleware) audit(ctx context.Context, tokenString string, err error) {
+	if m.Audit != nil {
+		m.Audit.Log(ctx, tokenString, err)
+	}
+}
+
+func (m *Middleware) handleError(w http.ResponseWriter, r *http.Request, err error) {
+	if m.ErrorHandler != nil {
+		m.ErrorHandler(w, r, err)
+		return
+	}
+
+	var scopes []string
+	if m.Validator != nil {
+		scopes = m.Validator.RequiredScopes
+	}
+	writeBearerError(w, err, scopes)
+}
+
+// RequireScopes 返回一个中间件，要求Context中的访问令牌具备所有指定的scope，
+// 必须放在Middleware之后使用
+func RequireScopes(scopes ...string) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			claims, ok := AccessTokenClaimsFromContext(r.Context())
+			if !ok {
+				writeBearerError(w, ErrNoBearerToken, scopes)
+				return
+			}
+			if !claims.HasAllScopes(scopes...) {
+				writeBearerError(w, &ValidationError{Inner: ErrInsufficientScope, Errors: ValidationErrorClaimsInvalid}, scopes)
+				return
+			}
+			next.ServeHTTP(w, r)
+		})
+	}
+}
+
+// TokenFromContext 返回Middleware放入Context中的Token
+func TokenFromContext(ctx context.Context) (*Token, bool) {
+	token, ok := ctx.Value(tokenContextKey).(*Token)
+	return token, ok
+}
+
+// AccessTokenClaimsFromContext 返回Middleware放入Context中的AccessTokenClaims
+func AccessTokenClaimsFromContext(ctx context.Context) (*AccessTokenClaims, bool) {
+	claims, ok := ctx.Value(accessTokenClaimsContextKey).(*AccessTokenClaims)
+	return claims, ok
+}
+
+// BearerToken 从请求的Authorization头部中提取Bearer令牌
+func BearerToken(r *http.Request) (string, error) {
+	auth := r.Header.Get("Authorization")
+	if len(auth) < 7 || !strings.EqualFold(auth[:7], "bearer ") {
+		return "", ErrNoBearerToken
+	}
+
+	token := strings.TrimSpace(auth[7:])
+	if token == "" {
+		return "", ErrNoBearerToken
+	}
+	return token, nil
+}
+
+func writeBearerError(w http.ResponseWriter, err error, scopes []string) {
+	if err == ErrNoBearerToken {
+		w.Header().Set("WWW-Authenticate", "Bearer")
+		http.Error(w, http.StatusText(http.StatusUnauthorized), http.StatusUnauthorized)
+		return
+	}
+
+	if ve, ok := err.(*ValidationError); ok && ve.Inner == ErrInsufficientScope {
+		w.Header().Set("WWW-Authenticate", fmt.Sprintf(`Bearer error="insufficient_scope", scope=%q`, strings.Join(scopes, " ")))
+		http.Error(w, http.StatusText(http.StatusForbidden), http.StatusForbidden)
+		return
+	}
+
+	w.Header().Set("WWW-Authenticate", `Bearer error="invalid_token"`)
+	http.Error(w, http.StatusText(http.StatusUnauthorized), http.StatusUnauthorized)
+}
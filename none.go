@@ -0,0 +1,55 @@
+package jwt
+
+import (
+	"errors"
+)
+
+// errors
+var (
+	ErrNoneSignatureDisallowed = errors.New("'none' signature type is not allowed")
+	ErrNoneSignatureInvalid    = errors.New("'none' signature must be empty")
+)
+
+// unsafeNoneKey 是未导出的类型，保证只有UnsafeAllowNoneSignatureType能通过检查
+type unsafeNoneKey string
+
+// UnsafeAllowNoneSignatureType 是使用none方法时必须传递的密钥。
+// 只有在明确需要未签名令牌时(例如通过回环地址通信)才能在Generate或KeyFunc中使用它
+const UnsafeAllowNoneSignatureType unsafeNoneKey = "none signing method allowed"
+
+// NoneMethod 实现RFC 7519的Unsecured JWT("alg": "none")，令牌没有签名
+type NoneMethod struct{}
+
+// None method
+var (
+	None *NoneMethod
+)
+
+func init() {
+	None = &NoneMethod{}
+	RegisterSigningMethod(None.Algorithm(), None)
+}
+
+// Algorithm 返回算法名称字符串
+func (m *NoneMethod) Algorithm() string {
+	return "none"
+}
+
+// Verify 只有key为UnsafeAllowNoneSignatureType且签名为空时才通过验证
+func (m *NoneMethod) Verify(signingString, signature string, key interface{}) error {
+	if _, ok := key.(unsafeNoneKey); !ok {
+		return ErrNoneSignatureDisallowed
+	}
+	if signature != "" {
+		return ErrNoneSignatureInvalid
+	}
+	return nil
+}
+
+// Sign 只有key为UnsafeAllowNoneSignatureType时才返回空签名
+func (m *NoneMethod) Sign(signingString string, key interface{}) (string, error) {
+	if _, ok := key.(unsafeNoneKey); !ok {
+		return "", ErrNoneSignatureDisallowed
+	}
+	return "", nil
+}
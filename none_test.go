@@ -0,0 +1,38 @@
+package jwt
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/gotoxu/assert"
+)
+
+func TestNoneMethod(t *testing.T) {
+	tokenString, err := NewWithClaims(None, MapClaims{"sub": "sidecar"}).Generate(UnsafeAllowNoneSignatureType)
+	assert.Nil(t, err)
+	assert.True(t, strings.HasSuffix(tokenString, "."))
+
+	token, err := Parse(tokenString, func(*Token) (interface{}, error) { return UnsafeAllowNoneSignatureType, nil })
+	assert.Nil(t, err)
+	assert.True(t, token.Valid)
+	assert.DeepEqual(t, token.Claims.(MapClaims)["sub"], "sidecar")
+
+	var keys = []interface{}{
+		nil,
+		"none signing method allowed",
+		[]byte("secret"),
+		jwtTestDefaultKey,
+	}
+	for _, key := range keys {
+		_, err = NewWithClaims(None, MapClaims{}).Generate(key)
+		assert.DeepEqual(t, err, ErrNoneSignatureDisallowed)
+
+		k := key
+		_, err = (&Parser{ValidMethods: []string{"none"}}).Parse(tokenString, func(*Token) (interface{}, error) { return k, nil })
+		assert.NotNil(t, err)
+		assert.DeepEqual(t, err.(*ValidationError).Inner, ErrNoneSignatureDisallowed)
+	}
+
+	_, err = Parse(tokenString+"c2ln", func(*Token) (interface{}, error) { return UnsafeAllowNoneSignatureType, nil })
+	assert.DeepEqual(t, err.(*ValidationError).Inner, ErrNoneSignatureInvalid)
+}
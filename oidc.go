@@ -0,0 +1,278 @@
+package jwt
+
+import (
+	"context"
+	"crypto"
+	"crypto/subtle"
+	"errors"
+	"fmt"
+	"time"
+)
+
+// errors
+var (
+	ErrIDTokenNonce    = errors.New("id token nonce does not match")
+	ErrIDTokenAuthTime = errors.New("id token auth_time is missing or too old")
+	ErrIDTokenACR      = errors.New("id token acr is not acceptable")
+	ErrIDTokenAtHash   = errors.New("id token at_hash does not match the access token")
+	ErrIDTokenCHash    = errors.New("id token c_hash does not match the authorization code")
+)
+
+// AddressClaim 是OIDC标准中的address claim
+type AddressClaim struct {
+	Formatted     string `json:"formatted,omitempty"`
+	StreetAddress string `json:"street_address,omitempty"`
+	Locality      string `json:"locality,omitempty"`
+	Region        string `json:"region,omitempty"`
+	PostalCode    string `json:"postal_code,omitempty"`
+	Country       string `json:"country,omitempty"`
+}
+
+// IDTokenClaims 是OpenID Connect ID Token的claims，包含标准的profile claims
+type IDTokenClaims struct {
+	Issuer          string       `json:"iss,omitempty"`
+	Subject         string       `json:"sub,omitempty"`
+	Audience        ClaimStrings `json:"aud,omitempty"`
+	ExpiresAt       int64        `json:"exp,omitempty"`
+	IssuedAt        int64        `json:"iat,omitempty"`
+	NotBefore       int64        `json:"nbf,omitempty"`
+	AuthTime        int64        `json:"auth_time,omitempty"`
+	Nonce           string       `json:"nonce,omitempty"`
+	ACR             string       `json:"acr,omitempty"`
+	AMR             []string     `json:"amr,omitempty"`
+	AuthorizedParty string       `json:"azp,omitempty"`
+	AccessTokenHash string       `json:"at_hash,omitempty"`
+	CodeHash        string       `json:"c_hash,omitempty"`
+
+	Name                string        `json:"name,omitempty"`
+	GivenName           string        `json:"given_name,omitempty"`
+	FamilyName          string        `json:"family_name,omitempty"`
+	MiddleName          string        `json:"middle_name,omitempty"`
+	Nickname            string        `json:"nickname,omitempty"`
+	PreferredUsername   string        `json:"preferred_username,omitempty"`
+	Profile             string        `json:"profile,omitempty"`
+	Picture             string        `json:"picture,omitempty"`
+	Website             string        `json:"website,omitempty"`
+	Email               string        `json:"email,omitempty"`
+	EmailVerified       bool          `json:"email_verified,omitempty"`
+	Gender              string        `json:"gender,omitempty"`
+	Birthdate           string        `json:"birthdate,omitempty"`
+	Zoneinfo            string        `json:"zoneinfo,omitempty"`
+	Locale              string        `json:"locale,omitempty"`
+	PhoneNumber         string        `json:"phone_number,omitempty"`
+	PhoneNumberVerified bool          `json:"phone_number_verified,omitempty"`
+	Address             *AddressClaim `json:"address,omitempty"`
+	UpdatedAt           int64         `json:"updated_at,omitempty"`
+}
+
+// Valid 验证ID Token中与时间相关的claims
+func (c *IDTokenClaims) Valid() error {
+	vErr := new(ValidationError)
+	now := TimeFunc().UTC().Unix()
+
+	if !verifyExp(c.ExpiresAt, now, false) {
+		delta := time.Unix(now, 0).Sub(time.Unix(c.ExpiresAt, 0))
+		vErr.Inner = fmt.Errorf("token is expired by %v", delta)
+		vErr.Errors |= ValidationErrorExpired
+	}
+
+	if !verifyIat(c.IssuedAt, now, false) {
+		vErr.Inner = fmt.Errorf("token used before issued")
+		vErr.Errors |= ValidationErrorIssuedAt
+	}
+
+	if !verifyNbf(c.NotBefore, now, false) {
+		vErr.Inner = fmt.Errorf("token is not valid yet")
+		vErr.Errors |= ValidationErrorNotValidYet
+	}
+
+	if vErr.valid() {
+		return nil
+	}
+
+	return vErr
+}
+
+// IDTokenValidator 按照OpenID Connect Core 1.0的规则验证ID Token
+type IDTokenValidator struct {
+	// Issuer 是期望的签发者，必须与iss完全一致
+	Issuer string
+	// ClientID 是依赖方的client_id，必须出现在aud中
+	ClientID string
+	// TrustedAudiences 是除ClientID之外允许出现在aud中的其他受众
+	TrustedAudiences []string
+	// Nonce 不为空时，令牌中的nonce必须与之相同
+	Nonce string
+	// MaxAge 大于0时要求auth_time存在，且认证时间距今不超过MaxAge
+	MaxAge time.Duration
+	// ACRValues 不为空时，acr必须是其中之一
+	ACRValues []string
+	// AccessToken 不为空时，at_hash必须存在并与之匹配
+	AccessToken string
+	// Code 不为空时，c_hash必须存在并与之匹配
+	Code string
+	// Parser 用于转换令牌，为空时使用默认的Parser
+	Parser *Parser
+}
+
+// Parse 转换并验证ID Token字符串
+func (v *IDTokenValidator) Parse(tokenString string, keyFunc KeyFunc) (*IDTokenClaims, error) {
+	return v.ParseContext(context.Background(), tokenString, ContextKeyFunc(keyFunc))
+}
+
+// ParseContext 与Parse相同，ctx会传递给keyFunc
+func (v *IDTokenValidator) ParseContext(ctx context.Context, tokenString string, keyFunc KeyFuncContext) (*IDTokenClaims, error) {
+	p := v.Parser
+	if p == nil {
+		p = new(Parser)
+	}
+
+	token, err := p.ParseWithClaimsContext(ctx, tokenString, &IDTokenClaims{}, keyFunc)
+	if err != nil {
+		return nil, err
+	}
+	return v.Validate(token)
+}
+
+// Validate 验证一个已经通过签名验证的ID Token
+func (v *IDTokenValidator) Validate(token *Token) (*IDTokenClaims, error) {
+	if token == nil || !token.Valid {
+		return nil, NewValidationError("token signature has not been verified", ValidationErrorUnverifiable)
+	}
+
+	claims, ok := token.Claims.(*IDTokenClaims)
+	if !ok {
+		return nil, NewValidationError("token claims are not IDTokenClaims", ValidationErrorClaimsInvalid)
+	}
+
+	if claims.Issuer == "" || !verifyIss(claims.Issuer, v.Issuer, true) {
+		return nil, NewValidationError("id token issuer is invalid", ValidationErrorIssuer)
+	}
+
+	if claims.Subject == "" {
+		return nil, NewValidationError("id token sub is missing", ValidationErrorClaimsInvalid)
+	}
+
+	if claims.ExpiresAt == 0 {
+		return nil, NewValidationError("id token exp is missing", ValidationErrorExpired)
+	}
+
+	if claims.IssuedAt == 0 {
+		return nil, NewValidationError("id token iat is missing", ValidationErrorIssuedAt)
+	}
+
+	if err := v.verifyAudience(claims); err != nil {
+		return nil, err
+	}
+
+	if v.Nonce != "" && subtle.ConstantTimeCompare([]byte(claims.Nonce), []byte(v.Nonce)) == 0 {
+		return nil, &ValidationError{Inner: ErrIDTokenNonce, Errors: ValidationErrorClaimsInvalid}
+	}
+
+	if v.MaxAge > 0 {
+		if claims.AuthTime == 0 {
+			return nil, &ValidationError{Inner: ErrIDTokenAuthTime, Errors: ValidationErrorClaimsInvalid}
+		}
+		if TimeFunc().After(time.Unix(claims.AuthTime, 0).Add(v.MaxAge)) {
+			return nil, &ValidationError{Inner: ErrIDTokenAuthTime, Errors: ValidationErrorClaimsInvalid}
+		}
+	}
+
+	if len(v.ACRValues) > 0 && !ClaimStrings(v.ACRValues).Contains(claims.ACR) {
+		return nil, &ValidationError{Inner: ErrIDTokenACR, Errors: ValidationErrorClaimsInvalid}
+	}
+
+	if v.AccessToken != "" {
+		if err := verifyTokenHash(token.Method, claims.AccessTokenHash, v.AccessToken); err != nil {
+			return nil, &ValidationError{Inner: ErrIDTokenAtHash, Errors: ValidationErrorClaimsInvalid}
+		}
+	}
+
+	if v.Code != "" {
+		if err := verifyTokenHash(token.Method, claims.CodeHash, v.Code); err != nil {
+			return nil, &ValidationError{Inner: ErrIDTokenCHash, Errors: ValidationErrorClaimsInvalid}
+		}
+	}
+
+	token.Verification.pass(CheckIDToken)
+	return claims, nil
+}
+
+func (v *IDTokenValidator) verifyAudience(claims *IDTokenClaims) error {
+	if !claims.Audience.Contains(v.ClientID) {
+		return NewValidationError("id token audience does not contain the client", ValidationErrorAudience)
+	}
+
+	trusted := ClaimStrings(append([]string{v.ClientID}, v.TrustedAudiences...))
+	for _, aud := range claims.Audience {
+		if !trusted.Contains(aud) {
+			return NewValidationError(fmt.Sprintf("id token audience %q is not trusted", aud), ValidationErrorAudience)
+		}
+	}
+
+	if len(claims.Audience) > 1 && claims.AuthorizedParty == "" {
+		return NewValidationError("id token with multiple audiences requires azp", ValidationErrorAudience)
+	}
+
+	if claims.AuthorizedParty != "" && subtle.ConstantTimeCompare([]byte(claims.AuthorizedParty), []byte(v.ClientID)) == 0 {
+		return NewValidationError("id token azp is not the client", ValidationErrorAudience)
+	}
+
+	return nil
+}
+
+// TokenHash 计算at_hash或c_hash的值：
+// 使用签名算法的哈希函数计算value的摘要，取左半部分进行base64url编码
+func TokenHash(method SigningMethod, value string) (string, error) {
+	hash, err := signingMethodHash(method)
+	if err != nil {
+		return "", err
+	}
+
+	hasher := hash.New()
+	hasher.Write([]byte(value))
+	sum := hasher.Sum(nil)
+
+	return EncodeSegment(sum[:len(sum)/2]), nil
+}
+
+func verifyTokenHash(method SigningMethod, claim, value string) error {
+	if claim == "" {
+		return ErrSignatureInvalid
+	}
+
+	expected, err := TokenHash(method, value)
+	if err != nil {
+		return err
+	}
+
+	if subtle.ConstantTimeCompare([]byte(claim), []byte(expected)) == 0 {
+		return ErrSignatureInvalid
+	}
+	return nil
+}
+
+// signingMethodHash 返回签名方法所使用的哈希函数
+func signingMethodHash(method SigningMethod) (crypto.Hash, error) {
+	var hash crypto.Hash
+	switch m := method.(type) {
+	case *HMACMethod:
+		hash = m.Hash
+	case *RSAMethod:
+		hash = m.Hash
+	case *RSAPSSMethod:
+		hash = m.Hash
+	case *ECDSAMethod:
+		hash = m.Hash
+	case *EdDSAMethod:
+		// Ed25519使用SHA-512
+		hash = crypto.SHA512
+	default:
+		return 0, ErrHashUnavailable
+	}
+
+	if !hash.Available() {
+		return 0, ErrHashUnavailable
+	}
+	return hash, nil
+}
@@ -0,0 +1,120 @@
+package jwt
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+)
+
+// errors
+var (
+	ErrProviderIssuerMismatch = errors.New("oidc: discovered issuer does not match the configured issuer")
+	ErrProviderMissingJWKSURI = errors.New("oidc: discovery document has no jwks_uri")
+)
+
+// ProviderMetadata 是OpenID Connect Discovery文档中的字段
+type ProviderMetadata struct {
+	Issuer                            string   `json:"issuer"`
+	AuthorizationEndpoint             string   `json:"authorization_endpoint,omitempty"`
+	TokenEndpoint                     string   `json:"token_endpoint,omitempty"`
+	UserinfoEndpoint                  string   `json:"userinfo_endpoint,omitempty"`
+	JWKSURI                           string   `json:"jwks_uri"`
+	RegistrationEndpoint              string   `json:"registration_endpoint,omitempty"`
+	ScopesSupported                   []string `json:"scopes_supported,omitempty"`
+	ResponseTypesSupported            []string `json:"response_types_supported,omitempty"`
+	SubjectTypesSupported             []string `json:"subject_types_supported,omitempty"`
+	IDTokenSigningAlgValuesSupported  []string `json:"id_token_signing_alg_values_supported,omitempty"`
+	ClaimsSupported                   []string `json:"claims_supported,omitempty"`
+	TokenEndpointAuthMethodsSupported []string `json:"token_endpoint_auth_methods_supported,omitempty"`
+}
+
+// Provider 表示一个通过Discovery文档配置的OpenID Connect身份提供者
+type Provider struct {
+	Metadata ProviderMetadata
+	keys     *RemoteKeySet
+}
+
+// NewProvider 从issuer/.well-known/openid-configuration获取Discovery文档并创建Provider，
+// client为空时使用http.DefaultClient
+func NewProvider(issuer string, client *http.Client) (*Provider, error) {
+	return NewProviderContext(context.Background(), issuer, client)
+}
+
+// NewProviderContext 与NewProvider相同，获取Discovery文档的请求在ctx取消时中止
+func NewProviderContext(ctx context.Context, issuer string, client *http.Client) (*Provider, error) {
+	wellKnown := strings.TrimSuffix(issuer, "/") + "/.well-known/openid-configuration"
+
+	data, err := fetchDocument(ctx, client, wellKnown)
+	if err != nil {
+		return nil, err
+	}
+
+	p := new(Provider)
+	if err = json.Unmarshal(data, &p.Metadata); err != nil {
+		return nil, fmt.Errorf("oidc: invalid discovery document: %v", err)
+	}
+
+	if p.Metadata.Issuer != issuer {
+		return nil, ErrProviderIssuerMismatch
+	}
+	if p.Metadata.JWKSURI == "" {
+		return nil, ErrProviderMissingJWKSURI
+	}
+
+	p.keys = NewRemoteKeySet(p.Metadata.JWKSURI, client)
+	return p, nil
+}
+
+// KeyFunc 使用jwks_uri中发布的密钥验证令牌
+func (p *Provider) KeyFunc(token *Token) (interface{}, error) {
+	return p.keys.KeyFunc(token)
+}
+
+// KeyFuncContext 与KeyFunc相同，获取jwks_uri的请求在ctx取消时中止
+func (p *Provider) KeyFuncContext(ctx context.Context, token *Token) (interface{}, error) {
+	return p.keys.KeyFuncContext(ctx, token)
+}
+
+// KeySet 返回Provider使用的远程JWK Set
+func (p *Provider) KeySet() *RemoteKeySet {
+	return p.keys
+}
+
+// Parser 返回一个只接受id_token_signing_alg_values_supported中已注册算法的Parser
+func (p *Provider) Parser() *Parser {
+	algs := p.Metadata.IDTokenSigningAlgValuesSupported
+	if len(algs) == 0 {
+		// OpenID Connect Discovery 1.0要求至少支持RS256
+		algs = []string{"RS256"}
+	}
+
+	methods := make([]string, 0, len(algs))
+	for _, alg := range algs {
+		if GetSigningMethod(alg) != nil {
+			methods = append(methods, alg)
+		}
+	}
+	return &Parser{ValidMethods: methods}
+}
+
+// IDTokenValidator 返回针对该Provider和指定client_id的ID Token验证器
+func (p *Provider) IDTokenValidator(clientID string) *IDTokenValidator {
+	return &IDTokenValidator{
+		Issuer:   p.Metadata.Issuer,
+		ClientID: clientID,
+		Parser:   p.Parser(),
+	}
+}
+
+// VerifyIDToken 使用Provider的密钥和算法转换并验证ID Token
+func (p *Provider) VerifyIDToken(tokenString, clientID string) (*IDTokenClaims, error) {
+	return p.IDTokenValidator(clientID).Parse(tokenString, p.KeyFunc)
+}
+
+// VerifyIDTokenContext 与VerifyIDToken相同，ctx会传递给密钥获取
+func (p *Provider) VerifyIDTokenContext(ctx context.Context, tokenString, clientID string) (*IDTokenClaims, error) {
+	return p.IDTokenValidator(clientID).ParseContext(ctx, tokenString, p.KeyFuncContext)
+}
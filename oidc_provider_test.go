@@ -0,0 +1,93 @@
+package jwt
+
+import (
+	"encoding/json"
+	"fmt"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/gotoxu/assert"
+)
+
+func newTestProviderServer(t *testing.T, issuerOverride string) (*httptest.Server, *int) {
+	pub := loadRSAPublicKeyFromDisk("test/sample_key.pub")
+	jwksRequests := 0
+
+	mux := http.NewServeMux()
+	var server *httptest.Server
+	mux.HandleFunc("/.well-known/openid-configuration", func(w http.ResponseWriter, r *http.Request) {
+		issuer := server.URL
+		if issuerOverride != "" {
+			issuer = issuerOverride
+		}
+		json.NewEncoder(w).Encode(ProviderMetadata{
+			Issuer:                           issuer,
+			JWKSURI:                          server.URL + "/jwks",
+			IDTokenSigningAlgValuesSupported: []string{"RS256", "unknown"},
+		})
+	})
+	mux.HandleFunc("/jwks", func(w http.ResponseWriter, r *http.Request) {
+		jwksRequests++
+		json.NewEncoder(w).Encode(JSONWebKeySet{Keys: []JSONWebKey{{
+			KeyType: "RSA",
+			KeyID:   "sample",
+			Use:     "sig",
+			N:       EncodeSegment(pub.N.Bytes()),
+			E:       EncodeSegment(big.NewInt(int64(pub.E)).Bytes()),
+		}}})
+	})
+	server = httptest.NewServer(mux)
+	return server, &jwksRequests
+}
+
+func TestProvider(t *testing.T) {
+	server, jwksRequests := newTestProviderServer(t, "")
+	defer server.Close()
+
+	provider, err := NewProvider(server.URL, server.Client())
+	assert.Nil(t, err)
+	assert.DeepEqual(t, provider.Parser().ValidMethods, []string{"RS256"})
+
+	now := time.Now()
+	token := NewWithClaims(RS256, &IDTokenClaims{
+		Issuer:    server.URL,
+		Subject:   "alice",
+		Audience:  ClaimStrings{"client"},
+		ExpiresAt: now.Add(time.Minute).Unix(),
+		IssuedAt:  now.Unix(),
+	})
+	token.Header["kid"] = "sample"
+	tokenString, err := token.Generate(loadRSAPrivateKeyFromDisk("test/sample_key"))
+	assert.Nil(t, err)
+
+	claims, err := provider.VerifyIDToken(tokenString, "client")
+	assert.Nil(t, err)
+	assert.DeepEqual(t, claims.Subject, "alice")
+
+	// 第二次验证应当命中缓存
+	_, err = provider.VerifyIDToken(tokenString, "client")
+	assert.Nil(t, err)
+	assert.DeepEqual(t, *jwksRequests, 1)
+
+	_, err = provider.VerifyIDToken(tokenString, "other-client")
+	assert.NotNil(t, err)
+
+	token.Header["kid"] = "rotated"
+	tokenString, _ = token.Generate(loadRSAPrivateKeyFromDisk("test/sample_key"))
+	_, err = provider.VerifyIDToken(tokenString, "client")
+	assert.NotNil(t, err)
+}
+
+func TestProviderIssuerMismatch(t *testing.T) {
+	server, _ := newTestProviderServer(t, "https://evil.example.com")
+	defer server.Close()
+
+	_, err := NewProvider(server.URL, server.Client())
+	assert.DeepEqual(t, err, ErrProviderIssuerMismatch)
+
+	_, err = NewProvider(fmt.Sprintf("%s/missing", server.URL), server.Client())
+	assert.NotNil(t, err)
+}
@@ -0,0 +1,157 @@
+package jwt
+
+import (
+	"testing"
+	"time"
+
+	"github.com/gotoxu/assert"
+)
+
+func makeIDToken(claims *IDTokenClaims) string {
+	s, err := NewWithClaims(HS256Method, claims).Generate(hmacTestKey)
+	if err != nil {
+		panic(err.Error())
+	}
+	return s
+}
+
+func validIDTokenClaims() *IDTokenClaims {
+	now := time.Now()
+	return &IDTokenClaims{
+		Issuer:    "https://issuer.example.com",
+		Subject:   "alice",
+		Audience:  ClaimStrings{"client"},
+		ExpiresAt: now.Add(time.Minute).Unix(),
+		IssuedAt:  now.Unix(),
+		AuthTime:  now.Add(-time.Minute).Unix(),
+		Nonce:     "n-0S6_WzA2Mj",
+		ACR:       "urn:mace:incommon:iap:silver",
+		Email:     "alice@example.com",
+	}
+}
+
+var idTokenTestData = []struct {
+	name      string
+	modify    func(c *IDTokenClaims)
+	validator IDTokenValidator
+	errors    uint32
+}{
+	{
+		"valid",
+		func(c *IDTokenClaims) {},
+		IDTokenValidator{Nonce: "n-0S6_WzA2Mj", MaxAge: time.Hour, ACRValues: []string{"urn:mace:incommon:iap:silver"}},
+		0,
+	},
+	{
+		"wrong issuer",
+		func(c *IDTokenClaims) { c.Issuer = "https://evil.example.com" },
+		IDTokenValidator{},
+		ValidationErrorIssuer,
+	},
+	{
+		"wrong audience",
+		func(c *IDTokenClaims) { c.Audience = ClaimStrings{"other"} },
+		IDTokenValidator{},
+		ValidationErrorAudience,
+	},
+	{
+		"untrusted extra audience",
+		func(c *IDTokenClaims) { c.Audience = ClaimStrings{"client", "other"}; c.AuthorizedParty = "client" },
+		IDTokenValidator{},
+		ValidationErrorAudience,
+	},
+	{
+		"multiple audiences without azp",
+		func(c *IDTokenClaims) { c.Audience = ClaimStrings{"client", "other"} },
+		IDTokenValidator{TrustedAudiences: []string{"other"}},
+		ValidationErrorAudience,
+	},
+	{
+		"multiple audiences with azp",
+		func(c *IDTokenClaims) { c.Audience = ClaimStrings{"client", "other"}; c.AuthorizedParty = "client" },
+		IDTokenValidator{TrustedAudiences: []string{"other"}},
+		0,
+	},
+	{
+		"nonce mismatch",
+		func(c *IDTokenClaims) { c.Nonce = "replayed" },
+		IDTokenValidator{Nonce: "n-0S6_WzA2Mj"},
+		ValidationErrorClaimsInvalid,
+	},
+	{
+		"auth_time too old",
+		func(c *IDTokenClaims) { c.AuthTime = time.Now().Add(-2 * time.Hour).Unix() },
+		IDTokenValidator{MaxAge: time.Hour},
+		ValidationErrorClaimsInvalid,
+	},
+	{
+		"acr not allowed",
+		func(c *IDTokenClaims) {},
+		IDTokenValidator{ACRValues: []string{"urn:mace:incommon:iap:gold"}},
+		ValidationErrorClaimsInvalid,
+	},
+	{
+		"missing exp",
+		func(c *IDTokenClaims) { c.ExpiresAt = 0 },
+		IDTokenValidator{},
+		ValidationErrorExpired,
+	},
+	{
+		"expired",
+		func(c *IDTokenClaims) { c.ExpiresAt = time.Now().Add(-time.Minute).Unix() },
+		IDTokenValidator{},
+		ValidationErrorExpired,
+	},
+}
+
+func TestIDTokenValidator(t *testing.T) {
+	keyFunc := func(*Token) (interface{}, error) { return hmacTestKey, nil }
+
+	for _, data := range idTokenTestData {
+		claims := validIDTokenClaims()
+		data.modify(claims)
+
+		v := data.validator
+		v.Issuer = "https://issuer.example.com"
+		v.ClientID = "client"
+
+		parsed, err := v.Parse(makeIDToken(claims), keyFunc)
+		if data.errors == 0 {
+			assert.Nil(t, err, data.name)
+			assert.DeepEqual(t, parsed.Email, "alice@example.com", data.name)
+			continue
+		}
+
+		assert.NotNil(t, err, data.name)
+		ve, ok := err.(*ValidationError)
+		assert.True(t, ok, data.name)
+		assert.True(t, ve.Errors&data.errors != 0, data.name)
+	}
+}
+
+func TestIDTokenHashes(t *testing.T) {
+	keyFunc := func(*Token) (interface{}, error) { return hmacTestKey, nil }
+
+	// OpenID Connect Core 1.0 附录A.3中的示例值
+	atHash, err := TokenHash(RS256, "jHkWEdUXMU1BwAsC4vtUsZwnNvTIxEl0z9K3vx5KF0Y")
+	assert.Nil(t, err)
+	assert.DeepEqual(t, atHash, "77QmUPtjPfzWtF2AnpK9RQ")
+
+	claims := validIDTokenClaims()
+	claims.AccessTokenHash, _ = TokenHash(HS256Method, "access-token")
+	claims.CodeHash, _ = TokenHash(HS256Method, "code")
+	tokenString := makeIDToken(claims)
+
+	v := IDTokenValidator{Issuer: claims.Issuer, ClientID: "client", AccessToken: "access-token", Code: "code"}
+	_, err = v.Parse(tokenString, keyFunc)
+	assert.Nil(t, err)
+
+	v.AccessToken = "other-token"
+	_, err = v.Parse(tokenString, keyFunc)
+	assert.NotNil(t, err)
+
+	v.AccessToken = ""
+	v.Code = "other-code"
+	_, err = v.Parse(tokenString, keyFunc)
+	assert.NotNil(t, err)
+}
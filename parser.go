@@ -2,6 +2,7 @@ package jwt
 
 import (
 	"bytes"
+	"context"
 	"encoding/json"
 	"fmt"
 	"strings"
@@ -12,12 +13,51 @@ import (
 // 因此你可以根据令牌头部中的属性来决定使用哪个密钥
 type KeyFunc func(*Token) (interface{}, error)
 
+// KeyFuncContext 是可以取消的KeyFunc，ctx来自ParseWithContext的调用者，
+// 获取远程密钥时应该遵守它的截止时间
+type KeyFuncContext func(ctx context.Context, token *Token) (interface{}, error)
+
+// ContextKeyFunc 将KeyFunc转换为忽略ctx的KeyFuncContext
+func ContextKeyFunc(keyFunc KeyFunc) KeyFuncContext {
+	if keyFunc == nil {
+		return nil
+	}
+	return func(_ context.Context, token *Token) (interface{}, error) {
+		return keyFunc(token)
+	}
+}
+
+// ContextValidator 可以由Claims实现，ParseWithClaimsContext会用ValidContext代替Valid验证claims
+type ContextValidator interface {
+	ValidContext(ctx context.Context) error
+}
+
 // Parser 是JWT Token string的转换器
 // 可以将字符串转换为Token对象
 type Parser struct {
 	ValidMethods         []string
 	UseJSONNumber        bool
 	SkipClaimsValidation bool
+
+	// ValidTypes 不为空时头部typ必须是其中之一，比较时忽略大小写和"application/"前缀
+	ValidTypes []string
+	// ValidContentTypes 不为空时头部cty必须是其中之一，比较方式与ValidTypes相同
+	ValidContentTypes []string
+	// CriticalHeaders 是可以理解的crit扩展参数及其处理函数，crit中未注册的参数会导致令牌被拒绝，
+	// 处理函数在签名验证通过之后调用
+	CriticalHeaders map[string]CriticalHeaderHandler
+	// Limits 限制令牌的大小和结构，为nil时不限制
+	Limits *Limits
+	// KeyPolicy 是验证签名时的密钥强度要求，为nil时使用DefaultKeyPolicy。
+	// 只应为验证旧令牌而放宽，签名总是使用默认策略
+	KeyPolicy *KeyPolicy
+	// Strict 为true时拒绝重复的JSON成员名、JSON对象之后的多余数据，
+	// 以及带有填充、非URL安全字符或非规范编码的段
+	Strict bool
+	// MaxKeyAttempts 是KeyFunc返回VerificationKeySet时最多尝试的密钥数量，为0时使用DefaultMaxKeyAttempts
+	MaxKeyAttempts int
+	// Hooks 是只对该Parser生效的Hook，在RegisterHook注册的全局Hook之后调用
+	Hooks []Hook
 }
 
 // Parse 转换，验证并返回一个Token对象
@@ -27,11 +67,33 @@ func (p *Parser) Parse(tokenString string, keyFunc KeyFunc) (*Token, error) {
 
 // ParseWithClaims 转换，验证并返回一个Token对象
 func (p *Parser) ParseWithClaims(tokenString string, claims Claims, keyFunc KeyFunc) (*Token, error) {
+	return p.ParseWithClaimsContext(context.Background(), tokenString, claims, ContextKeyFunc(keyFunc))
+}
+
+// ParseWithContext 与Parse相同，ctx会传递给keyFunc和claims的ValidContext方法
+func (p *Parser) ParseWithContext(ctx context.Context, tokenString string, keyFunc KeyFuncContext) (*Token, error) {
+	return p.ParseWithClaimsContext(ctx, tokenString, MapClaims{}, keyFunc)
+}
+
+// ParseWithClaimsContext 与ParseWithClaims相同，ctx会传递给keyFunc和claims的ValidContext方法
+func (p *Parser) ParseWithClaimsContext(ctx context.Context, tokenString string, claims Claims, keyFunc KeyFuncContext) (*Token, error) {
+	hooks := p.hooks()
+	run := startHooks(ctx, hooks, OpParse, nil, ValidationErrorMalformed)
+	token, err := p.parseWithClaims(ctx, hooks, tokenString, claims, keyFunc)
+	run.setRaw(tokenString)
+	run.end(token, err)
+	return token, err
+}
+
+func (p *Parser) parseWithClaims(ctx context.Context, hooks []Hook, tokenString string, claims Claims, keyFunc KeyFuncContext) (*Token, error) {
 	token, parts, err := p.ParseUnverified(tokenString, claims)
 	if err != nil {
 		return token, err
 	}
 
+	verification := p.newVerification(token)
+	token.Verification = verification
+
 	if p.ValidMethods != nil {
 		var signingMethodValid = false
 		var alg = token.Method.Algorithm()
@@ -44,34 +106,76 @@ func (p *Parser) ParseWithClaims(tokenString string, claims Claims, keyFunc KeyF
 		if !signingMethodValid {
 			return token, NewValidationError(fmt.Sprintf("signing method %v is invalid", alg), ValidationErrorSignatureInvalid)
 		}
+		verification.pass(CheckMethod)
 	}
 
+	if err = p.validateHeader(token); err != nil {
+		return token, err
+	}
+	verification.pass(CheckHeader)
+
 	var key interface{}
 	if keyFunc == nil {
 		return token, NewValidationError("no Keyfunc was provided", ValidationErrorUnverifiable)
 	}
 
-	if key, err = keyFunc(token); err != nil {
+	run := startHooks(ctx, hooks, OpKeyLookup, token, ValidationErrorUnverifiable)
+	key, err = keyFunc(ctx, token)
+	run.end(token, err)
+	if err != nil {
 		if ve, ok := err.(*ValidationError); ok {
 			return token, ve
 		}
 		return token, &ValidationError{Inner: err, Errors: ValidationErrorUnverifiable}
 	}
+	verification.pass(CheckKey)
 
 	vErr := &ValidationError{}
 
 	if !p.SkipClaimsValidation {
-		if err := token.Claims.Valid(); err != nil {
+		run := startHooks(ctx, hooks, OpClaims, token, ValidationErrorClaimsInvalid)
+		err := validateClaims(ctx, token.Claims)
+		run.end(token, err)
+		if err != nil {
 			if e, ok := err.(*ValidationError); !ok {
 				vErr = &ValidationError{Inner: err, Errors: ValidationErrorClaimsInvalid}
 			} else {
 				vErr = e
 			}
+		} else {
+			verification.pass(CheckClaims)
 		}
 	}
 
 	token.Signature = parts[2]
-	if err = token.Method.Verify(strings.Join(parts[0:2], "."), token.Signature, key); err != nil {
+	signingString := strings.Join(parts[0:2], ".")
+	run = startHooks(ctx, hooks, OpVerify, token, ValidationErrorSignatureInvalid)
+	if set, ok := key.(*VerificationKeySet); ok {
+		key, err = p.verifyKeySet(token.Method, signingString, token.Signature, set)
+	} else {
+		err = p.verify(token.Method, signingString, token.Signature, key)
+	}
+	run.end(token, err)
+	if err == nil {
+		token.Key = key
+		verification.Thumbprint = keyThumbprint(key)
+		verification.pass(CheckSignature)
+
+		// crit处理函数只能看到已经验证的头部
+		err = p.runCriticalHandlers(token)
+	}
+
+	if ve, ok := err.(*ValidationError); ok {
+		// 保留claims验证已经记录的错误，ve没有Inner时用它本身保留错误信息
+		if vErr.Inner == nil && vErr.text == "" {
+			if ve.Inner != nil {
+				vErr.Inner = ve.Inner
+			} else {
+				vErr.Inner = ve
+			}
+		}
+		vErr.Errors |= ve.Errors
+	} else if err != nil {
 		vErr.Inner = err
 		vErr.Errors |= ValidationErrorSignatureInvalid
 	}
@@ -86,6 +190,12 @@ func (p *Parser) ParseWithClaims(tokenString string, claims Claims, keyFunc KeyF
 
 // ParseUnverified 转换令牌但并不验证令牌签名
 func (p *Parser) ParseUnverified(tokenString string, claims Claims) (token *Token, parts []string, err error) {
+	if p.Limits != nil {
+		if err = p.Limits.checkToken(tokenString); err != nil {
+			return nil, nil, err
+		}
+	}
+
 	parts = strings.Split(tokenString, ".")
 	if len(parts) != 3 {
 		return nil, parts, NewValidationError("token contains an invalid number of segments", ValidationErrorMalformed)
@@ -94,13 +204,17 @@ func (p *Parser) ParseUnverified(tokenString string, claims Claims) (token *Toke
 	token = &Token{Raw: tokenString}
 
 	var headerBytes []byte
-	if headerBytes, err = DecodeSegment(parts[0]); err != nil {
+	if headerBytes, err = p.decodeSegment(parts[0]); err != nil {
 		if strings.HasPrefix(strings.ToLower(tokenString), "bearer ") {
 			return token, parts, NewValidationError("tokenstring should not contain 'bearer '", ValidationErrorMalformed)
 		}
 		return token, parts, &ValidationError{Inner: err, Errors: ValidationErrorMalformed}
 	}
 
+	if err = p.checkJSON(headerBytes, true); err != nil {
+		return token, parts, err
+	}
+
 	if err = json.Unmarshal(headerBytes, &token.Header); err != nil {
 		return token, parts, &ValidationError{Inner: err, Errors: ValidationErrorMalformed}
 	}
@@ -108,10 +222,14 @@ func (p *Parser) ParseUnverified(tokenString string, claims Claims) (token *Toke
 	var claimBytes []byte
 	token.Claims = claims
 
-	if claimBytes, err = DecodeSegment(parts[1]); err != nil {
+	if claimBytes, err = p.decodeSegment(parts[1]); err != nil {
 		return token, parts, &ValidationError{Inner: err, Errors: ValidationErrorMalformed}
 	}
 
+	if err = p.checkJSON(claimBytes, false); err != nil {
+		return token, parts, err
+	}
+
 	dec := json.NewDecoder(bytes.NewBuffer(claimBytes))
 	if p.UseJSONNumber {
 		dec.UseNumber()
@@ -130,6 +248,12 @@ func (p *Parser) ParseUnverified(tokenString string, claims Claims) (token *Toke
 		return token, parts, &ValidationError{Inner: err, Errors: ValidationErrorMalformed}
 	}
 
+	if p.Strict {
+		if _, err = DecodeSegmentStrict(parts[2]); err != nil {
+			return token, parts, &ValidationError{Inner: err, Errors: ValidationErrorMalformed}
+		}
+	}
+
 	if method, ok := token.Header["alg"].(string); ok {
 		if token.Method = GetSigningMethod(method); token.Method == nil {
 			return token, parts, NewValidationError("signing method (alg) is unavailable.", ValidationErrorUnverifiable)
@@ -140,3 +264,52 @@ func (p *Parser) ParseUnverified(tokenString string, claims Claims) (token *Toke
 
 	return token, parts, nil
 }
+
+func (p *Parser) decodeSegment(seg string) ([]byte, error) {
+	if p.Strict {
+		return DecodeSegmentStrict(seg)
+	}
+	return DecodeSegment(seg)
+}
+
+// checkJSON 按照Limits和Strict检查解码后的头部或载荷
+func (p *Parser) checkJSON(data []byte, header bool) error {
+	if p.Limits == nil && !p.Strict {
+		return nil
+	}
+
+	s := &jsonScanner{strict: p.Strict}
+	if p.Limits != nil {
+		s.maxDepth = p.Limits.MaxJSONDepth
+		if header {
+			s.maxMembers = p.Limits.MaxHeaderMembers
+		} else {
+			s.maxMembers = p.Limits.MaxClaims
+		}
+	}
+
+	if err := s.scan(data); err != nil {
+		return &ValidationError{Inner: err, Errors: ValidationErrorMalformed}
+	}
+	return nil
+}
+
+// verify 使用Parser的KeyPolicy验证签名，自定义签名方法直接调用其Verify方法
+func (p *Parser) verify(method SigningMethod, signingString, signature string, key interface{}) error {
+	if pv, ok := method.(policyVerifier); ok {
+		policy := p.KeyPolicy
+		if policy == nil {
+			policy = defaultKeyPolicy
+		}
+		return pv.verifyWithPolicy(signingString, signature, key, policy)
+	}
+	return method.Verify(signingString, signature, key)
+}
+
+// validateClaims 优先使用claims的ValidContext方法
+func validateClaims(ctx context.Context, claims Claims) error {
+	if cv, ok := claims.(ContextValidator); ok {
+		return cv.ValidContext(ctx)
+	}
+	return claims.Valid()
+}
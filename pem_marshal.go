@@ -0,0 +1,91 @@
+package jwt
+
+import (
+	"crypto/ecdsa"
+	"crypto/rsa"
+	"crypto/x509"
+	"encoding/pem"
+	"errors"
+)
+
+// PEMFormat 表示密钥的PEM编码格式
+type PEMFormat string
+
+// 支持的PEM编码格式
+const (
+	// PKCS1 是RSA专用的私钥("RSA PRIVATE KEY")或公钥("RSA PUBLIC KEY")格式
+	PKCS1 PEMFormat = "pkcs1"
+	// PKCS8 是通用的私钥格式("PRIVATE KEY")
+	PKCS8 PEMFormat = "pkcs8"
+	// SEC1 是EC专用的私钥格式("EC PRIVATE KEY")
+	SEC1 PEMFormat = "sec1"
+	// PKIX 是通用的公钥格式("PUBLIC KEY")
+	PKIX PEMFormat = "pkix"
+)
+
+// errors
+var (
+	ErrUnsupportedPEMFormat = errors.New("pem format is not supported for this key type")
+)
+
+// MarshalPrivateKeyToPEM 将RSA、ECDSA或Ed25519私钥编码为指定格式的PEM
+func MarshalPrivateKeyToPEM(key interface{}, format PEMFormat) ([]byte, error) {
+	var block *pem.Block
+
+	switch format {
+	case PKCS1:
+		k, ok := key.(*rsa.PrivateKey)
+		if !ok {
+			return nil, ErrUnsupportedPEMFormat
+		}
+		block = &pem.Block{Type: "RSA PRIVATE KEY", Bytes: x509.MarshalPKCS1PrivateKey(k)}
+	case SEC1:
+		k, ok := key.(*ecdsa.PrivateKey)
+		if !ok {
+			return nil, ErrUnsupportedPEMFormat
+		}
+		der, err := x509.MarshalECPrivateKey(k)
+		if err != nil {
+			return nil, err
+		}
+		block = &pem.Block{Type: "EC PRIVATE KEY", Bytes: der}
+	case PKCS8:
+		der, err := x509.MarshalPKCS8PrivateKey(key)
+		if err != nil {
+			return nil, err
+		}
+		block = &pem.Block{Type: "PRIVATE KEY", Bytes: der}
+	default:
+		return nil, ErrUnsupportedPEMFormat
+	}
+
+	return pem.EncodeToMemory(block), nil
+}
+
+// MarshalPublicKeyToPEM 将公钥编码为指定格式的PEM，传入私钥时编码其公钥部分
+func MarshalPublicKeyToPEM(key interface{}, format PEMFormat) ([]byte, error) {
+	pub, err := PublicKeyOf(key)
+	if err != nil {
+		return nil, err
+	}
+
+	var block *pem.Block
+	switch format {
+	case PKCS1:
+		k, ok := pub.(*rsa.PublicKey)
+		if !ok {
+			return nil, ErrUnsupportedPEMFormat
+		}
+		block = &pem.Block{Type: "RSA PUBLIC KEY", Bytes: x509.MarshalPKCS1PublicKey(k)}
+	case PKIX:
+		der, err := x509.MarshalPKIXPublicKey(pub)
+		if err != nil {
+			return nil, err
+		}
+		block = &pem.Block{Type: "PUBLIC KEY", Bytes: der}
+	default:
+		return nil, ErrUnsupportedPEMFormat
+	}
+
+	return pem.EncodeToMemory(block), nil
+}
@@ -0,0 +1,457 @@
+package jwt
+
+import (
+	"crypto"
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"crypto/sha1"
+	"crypto/sha256"
+	"crypto/sha512"
+	"crypto/subtle"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/asn1"
+	"encoding/pem"
+	"errors"
+	"hash"
+
+	"golang.org/x/crypto/pbkdf2"
+	"golang.org/x/crypto/scrypt"
+)
+
+// KDF 表示PBES2使用的密钥派生函数
+type KDF int
+
+// 支持的密钥派生函数
+const (
+	// PBKDF2 使用HMAC-SHA256的PBKDF2
+	PBKDF2 KDF = iota
+	// Scrypt 使用scrypt
+	Scrypt
+)
+
+// PBECipher 表示PBES2使用的对称加密算法
+type PBECipher int
+
+// 支持的对称加密算法
+const (
+	AES256CBC PBECipher = iota
+	AES192CBC
+	AES128CBC
+	AES256GCM
+	AES192GCM
+	AES128GCM
+)
+
+// 加密参数的默认值
+const (
+	DefaultPBKDF2Iterations = 600000
+	DefaultScryptN          = 1 << 15
+	DefaultScryptR          = 8
+	DefaultScryptP          = 1
+)
+
+// 接受的密钥派生参数上限，防止恶意密钥文件消耗过多CPU或内存。
+// scrypt的内存约为128*r*(n+p)字节，计算量与n*r*p成正比
+const (
+	maxPBKDF2Iterations = 1 << 20
+	maxScryptMemory     = 1 << 30
+	maxScryptCost       = 1 << 24
+)
+
+// errors
+var (
+	ErrPKCS8Decryption           = errors.New("failed to decrypt PKCS8 private key: wrong password or corrupt data")
+	ErrUnsupportedPKCS8Algorithm = errors.New("PKCS8 private key encryption algorithm is not supported")
+	ErrEncryptedPrivateKey       = errors.New("private key is encrypted, a password is required")
+	ErrLegacyPEMEncryption       = errors.New("legacy PEM encryption (Proc-Type/DEK-Info) is insecure and not accepted, use PBES2 encrypted PKCS8 or the FromLegacyPEM functions")
+)
+
+// decryptLegacyPEM 解密RFC 1423格式(Proc-Type: 4,ENCRYPTED)的PEM块。
+// 这种格式没有完整性保护，错误的密码可能得到错误的密钥而不报错，密钥派生也很弱，
+// 只供显式调用的FromLegacyPEM函数使用
+func decryptLegacyPEM(key []byte, password string) ([]byte, error) {
+	block, _ := pem.Decode(key)
+	if block == nil {
+		return nil, ErrKeyMustBePEMEncoded
+	}
+	return x509.DecryptPEMBlock(block, []byte(password))
+}
+
+var (
+	oidPBES2          = asn1.ObjectIdentifier{1, 2, 840, 113549, 1, 5, 13}
+	oidPBKDF2         = asn1.ObjectIdentifier{1, 2, 840, 113549, 1, 5, 12}
+	oidScrypt         = asn1.ObjectIdentifier{1, 3, 6, 1, 4, 1, 11591, 4, 11}
+	oidHMACWithSHA1   = asn1.ObjectIdentifier{1, 2, 840, 113549, 2, 7}
+	oidHMACWithSHA256 = asn1.ObjectIdentifier{1, 2, 840, 113549, 2, 9}
+	oidHMACWithSHA384 = asn1.ObjectIdentifier{1, 2, 840, 113549, 2, 10}
+	oidHMACWithSHA512 = asn1.ObjectIdentifier{1, 2, 840, 113549, 2, 11}
+)
+
+// pbeCipher 保存加密算法的OID、密钥长度以及是否为GCM模式
+type pbeCipher struct {
+	oid     asn1.ObjectIdentifier
+	keySize int
+	gcm     bool
+}
+
+var pbeCiphers = map[PBECipher]pbeCipher{
+	AES128CBC: {asn1.ObjectIdentifier{2, 16, 840, 1, 101, 3, 4, 1, 2}, 16, false},
+	AES192CBC: {asn1.ObjectIdentifier{2, 16, 840, 1, 101, 3, 4, 1, 22}, 24, false},
+	AES256CBC: {asn1.ObjectIdentifier{2, 16, 840, 1, 101, 3, 4, 1, 42}, 32, false},
+	AES128GCM: {asn1.ObjectIdentifier{2, 16, 840, 1, 101, 3, 4, 1, 6}, 16, true},
+	AES192GCM: {asn1.ObjectIdentifier{2, 16, 840, 1, 101, 3, 4, 1, 26}, 24, true},
+	AES256GCM: {asn1.ObjectIdentifier{2, 16, 840, 1, 101, 3, 4, 1, 46}, 32, true},
+}
+
+// EncryptionOptions 是加密PKCS8私钥的参数，零值使用PBKDF2和AES-256-CBC
+type EncryptionOptions struct {
+	KDF    KDF
+	Cipher PBECipher
+	// Iterations 是PBKDF2的迭代次数，为0时使用DefaultPBKDF2Iterations
+	Iterations int
+	// ScryptN、ScryptR和ScryptP 是scrypt的参数，为0时使用对应的默认值
+	ScryptN int
+	ScryptR int
+	ScryptP int
+}
+
+type encryptedPrivateKeyInfo struct {
+	Algorithm     pkix.AlgorithmIdentifier
+	EncryptedData []byte
+}
+
+type pbes2Params struct {
+	KeyDerivationFunc pkix.AlgorithmIdentifier
+	EncryptionScheme  pkix.AlgorithmIdentifier
+}
+
+type pbkdf2Params struct {
+	Salt           []byte
+	IterationCount int
+	KeyLength      int                      `asn1:"optional"`
+	PRF            pkix.AlgorithmIdentifier `asn1:"optional"`
+}
+
+type scryptParams struct {
+	Salt                     []byte
+	CostParameter            int
+	BlockSize                int
+	ParallelizationParameter int
+	KeyLength                int `asn1:"optional"`
+}
+
+type gcmParams struct {
+	Nonce  []byte
+	ICVLen int `asn1:"optional,default:12"`
+}
+
+// MarshalEncryptedPrivateKeyToPEM 将RSA、ECDSA或Ed25519私钥编码为PBES2加密的PKCS8("ENCRYPTED PRIVATE KEY")，
+// opts为nil时使用PBKDF2和AES-256-CBC
+func MarshalEncryptedPrivateKeyToPEM(key interface{}, password []byte, opts *EncryptionOptions) ([]byte, error) {
+	der, err := EncryptPKCS8PrivateKey(key, password, opts)
+	if err != nil {
+		return nil, err
+	}
+	return pem.EncodeToMemory(&pem.Block{Type: "ENCRYPTED PRIVATE KEY", Bytes: der}), nil
+}
+
+// EncryptPKCS8PrivateKey 将私钥编码为DER格式的PBES2加密PKCS8
+func EncryptPKCS8PrivateKey(key interface{}, password []byte, opts *EncryptionOptions) ([]byte, error) {
+	if opts == nil {
+		opts = &EncryptionOptions{}
+	}
+
+	c, ok := pbeCiphers[opts.Cipher]
+	if !ok {
+		return nil, ErrUnsupportedPKCS8Algorithm
+	}
+
+	plaintext, err := x509.MarshalPKCS8PrivateKey(key)
+	if err != nil {
+		return nil, err
+	}
+
+	salt := make([]byte, 16)
+	if _, err = rand.Read(salt); err != nil {
+		return nil, err
+	}
+
+	var kdf pkix.AlgorithmIdentifier
+	var derivedKey []byte
+	switch opts.KDF {
+	case PBKDF2:
+		iterations := opts.Iterations
+		if iterations <= 0 {
+			iterations = DefaultPBKDF2Iterations
+		}
+		if iterations > maxPBKDF2Iterations {
+			return nil, ErrUnsupportedPKCS8Algorithm
+		}
+		derivedKey = pbkdf2.Key(password, salt, iterations, c.keySize, sha256.New)
+		kdf.Algorithm = oidPBKDF2
+		kdf.Parameters.FullBytes, err = asn1.Marshal(pbkdf2Params{
+			Salt:           salt,
+			IterationCount: iterations,
+			PRF:            pkix.AlgorithmIdentifier{Algorithm: oidHMACWithSHA256, Parameters: asn1.NullRawValue},
+		})
+	case Scrypt:
+		n, r, p := opts.ScryptN, opts.ScryptR, opts.ScryptP
+		if n <= 0 {
+			n = DefaultScryptN
+		}
+		if r <= 0 {
+			r = DefaultScryptR
+		}
+		if p <= 0 {
+			p = DefaultScryptP
+		}
+		if !validScryptParams(n, r, p) {
+			return nil, ErrUnsupportedPKCS8Algorithm
+		}
+		if derivedKey, err = scrypt.Key(password, salt, n, r, p, c.keySize); err != nil {
+			return nil, err
+		}
+		kdf.Algorithm = oidScrypt
+		kdf.Parameters.FullBytes, err = asn1.Marshal(scryptParams{
+			Salt:                     salt,
+			CostParameter:            n,
+			BlockSize:                r,
+			ParallelizationParameter: p,
+		})
+	default:
+		return nil, ErrUnsupportedPKCS8Algorithm
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	block, err := aes.NewCipher(derivedKey)
+	if err != nil {
+		return nil, err
+	}
+
+	var scheme pkix.AlgorithmIdentifier
+	var ciphertext []byte
+	scheme.Algorithm = c.oid
+	if c.gcm {
+		aead, err := cipher.NewGCM(block)
+		if err != nil {
+			return nil, err
+		}
+		nonce := make([]byte, aead.NonceSize())
+		if _, err = rand.Read(nonce); err != nil {
+			return nil, err
+		}
+		ciphertext = aead.Seal(nil, nonce, plaintext, nil)
+		scheme.Parameters.FullBytes, err = asn1.Marshal(gcmParams{Nonce: nonce, ICVLen: aead.Overhead()})
+		if err != nil {
+			return nil, err
+		}
+	} else {
+		iv := make([]byte, aes.BlockSize)
+		if _, err = rand.Read(iv); err != nil {
+			return nil, err
+		}
+		padding := aes.BlockSize - len(plaintext)%aes.BlockSize
+		for i := 0; i < padding; i++ {
+			plaintext = append(plaintext, byte(padding))
+		}
+		ciphertext = make([]byte, len(plaintext))
+		cipher.NewCBCEncrypter(block, iv).CryptBlocks(ciphertext, plaintext)
+		if scheme.Parameters.FullBytes, err = asn1.Marshal(iv); err != nil {
+			return nil, err
+		}
+	}
+
+	params, err := asn1.Marshal(pbes2Params{KeyDerivationFunc: kdf, EncryptionScheme: scheme})
+	if err != nil {
+		return nil, err
+	}
+
+	return asn1.Marshal(encryptedPrivateKeyInfo{
+		Algorithm:     pkix.AlgorithmIdentifier{Algorithm: oidPBES2, Parameters: asn1.RawValue{FullBytes: params}},
+		EncryptedData: ciphertext,
+	})
+}
+
+// ParseEncryptedPrivateKeyFromPEM 解析PBES2加密的PKCS8私钥("ENCRYPTED PRIVATE KEY")
+func ParseEncryptedPrivateKeyFromPEM(data []byte, password []byte) (crypto.PrivateKey, error) {
+	block, _ := pem.Decode(data)
+	if block == nil {
+		return nil, ErrKeyMustBePEMEncoded
+	}
+	if block.Type != "ENCRYPTED PRIVATE KEY" {
+		return nil, ErrUnsupportedPKCS8Algorithm
+	}
+	return DecryptPKCS8PrivateKey(block.Bytes, password)
+}
+
+// DecryptPKCS8PrivateKey 解密DER格式的PBES2加密PKCS8私钥
+func DecryptPKCS8PrivateKey(der []byte, password []byte) (crypto.PrivateKey, error) {
+	var info encryptedPrivateKeyInfo
+	if rest, err := asn1.Unmarshal(der, &info); err != nil {
+		return nil, err
+	} else if len(rest) > 0 {
+		return nil, asn1.SyntaxError{Msg: "trailing data after encrypted private key"}
+	}
+	if !info.Algorithm.Algorithm.Equal(oidPBES2) {
+		return nil, ErrUnsupportedPKCS8Algorithm
+	}
+
+	var params pbes2Params
+	if _, err := asn1.Unmarshal(info.Algorithm.Parameters.FullBytes, &params); err != nil {
+		return nil, err
+	}
+
+	var c pbeCipher
+	found := false
+	for _, candidate := range pbeCiphers {
+		if candidate.oid.Equal(params.EncryptionScheme.Algorithm) {
+			c, found = candidate, true
+			break
+		}
+	}
+	if !found {
+		return nil, ErrUnsupportedPKCS8Algorithm
+	}
+
+	derivedKey, err := deriveKey(params.KeyDerivationFunc, password, c.keySize)
+	if err != nil {
+		return nil, err
+	}
+
+	block, err := aes.NewCipher(derivedKey)
+	if err != nil {
+		return nil, err
+	}
+
+	var plaintext []byte
+	if c.gcm {
+		var gp gcmParams
+		if _, err = asn1.Unmarshal(params.EncryptionScheme.Parameters.FullBytes, &gp); err != nil {
+			return nil, err
+		}
+		if len(gp.Nonce) == 0 || gp.ICVLen < 12 || gp.ICVLen > 16 {
+			return nil, ErrUnsupportedPKCS8Algorithm
+		}
+		aead, err := cipher.NewGCMWithNonceSize(block, len(gp.Nonce))
+		if err != nil {
+			return nil, err
+		}
+		if gp.ICVLen != aead.Overhead() {
+			if len(gp.Nonce) != 12 {
+				return nil, ErrUnsupportedPKCS8Algorithm
+			}
+			if aead, err = cipher.NewGCMWithTagSize(block, gp.ICVLen); err != nil {
+				return nil, err
+			}
+		}
+		if plaintext, err = aead.Open(nil, gp.Nonce, info.EncryptedData, nil); err != nil {
+			return nil, ErrPKCS8Decryption
+		}
+	} else {
+		var iv []byte
+		if _, err = asn1.Unmarshal(params.EncryptionScheme.Parameters.FullBytes, &iv); err != nil {
+			return nil, err
+		}
+		if len(iv) != aes.BlockSize {
+			return nil, ErrUnsupportedPKCS8Algorithm
+		}
+		ciphertext := info.EncryptedData
+		if len(ciphertext) == 0 || len(ciphertext)%aes.BlockSize != 0 {
+			return nil, ErrPKCS8Decryption
+		}
+		plaintext = make([]byte, len(ciphertext))
+		cipher.NewCBCDecrypter(block, iv).CryptBlocks(plaintext, ciphertext)
+		if plaintext, err = unpadPKCS7(plaintext); err != nil {
+			return nil, err
+		}
+	}
+
+	key, err := x509.ParsePKCS8PrivateKey(plaintext)
+	if err != nil {
+		// 错误的密码在CBC模式下有一定概率得到合法的填充
+		return nil, ErrPKCS8Decryption
+	}
+	return key, nil
+}
+
+// deriveKey 按照PBES2参数中的密钥派生函数从密码派生出keySize字节的密钥
+func deriveKey(kdf pkix.AlgorithmIdentifier, password []byte, keySize int) ([]byte, error) {
+	switch {
+	case kdf.Algorithm.Equal(oidPBKDF2):
+		var params pbkdf2Params
+		if _, err := asn1.Unmarshal(kdf.Parameters.FullBytes, &params); err != nil {
+			return nil, err
+		}
+		if params.KeyLength != 0 && params.KeyLength != keySize {
+			return nil, ErrUnsupportedPKCS8Algorithm
+		}
+		if params.IterationCount <= 0 || params.IterationCount > maxPBKDF2Iterations {
+			return nil, ErrUnsupportedPKCS8Algorithm
+		}
+
+		var h func() hash.Hash
+		switch prf := params.PRF.Algorithm; {
+		case len(prf) == 0, prf.Equal(oidHMACWithSHA1):
+			h = sha1.New
+		case prf.Equal(oidHMACWithSHA256):
+			h = sha256.New
+		case prf.Equal(oidHMACWithSHA384):
+			h = sha512.New384
+		case prf.Equal(oidHMACWithSHA512):
+			h = sha512.New
+		default:
+			return nil, ErrUnsupportedPKCS8Algorithm
+		}
+		return pbkdf2.Key(password, params.Salt, params.IterationCount, keySize, h), nil
+
+	case kdf.Algorithm.Equal(oidScrypt):
+		var params scryptParams
+		if _, err := asn1.Unmarshal(kdf.Parameters.FullBytes, &params); err != nil {
+			return nil, err
+		}
+		if params.KeyLength != 0 && params.KeyLength != keySize {
+			return nil, ErrUnsupportedPKCS8Algorithm
+		}
+		n, r, p := params.CostParameter, params.BlockSize, params.ParallelizationParameter
+		if !validScryptParams(n, r, p) {
+			return nil, ErrUnsupportedPKCS8Algorithm
+		}
+		return scrypt.Key(password, params.Salt, n, r, p, keySize)
+	}
+
+	return nil, ErrUnsupportedPKCS8Algorithm
+}
+
+// validScryptParams 检查scrypt参数是否在maxScryptMemory和maxScryptCost之内，计算过程不会溢出
+func validScryptParams(n, r, p int) bool {
+	if n <= 1 || r <= 0 || p <= 0 || r > maxScryptMemory/128 {
+		return false
+	}
+	// n+p不能超过limit，此时n*r也不会溢出
+	limit := maxScryptMemory / 128 / r
+	if n >= limit || p > limit-n {
+		return false
+	}
+	return p <= maxScryptCost/(n*r)
+}
+
+// unpadPKCS7 去掉CBC模式的PKCS7填充
+func unpadPKCS7(data []byte) ([]byte, error) {
+	padding := int(data[len(data)-1])
+	if padding == 0 || padding > aes.BlockSize || padding > len(data) {
+		return nil, ErrPKCS8Decryption
+	}
+
+	expected := make([]byte, padding)
+	for i := range expected {
+		expected[i] = byte(padding)
+	}
+	if subtle.ConstantTimeCompare(data[len(data)-padding:], expected) != 1 {
+		return nil, ErrPKCS8Decryption
+	}
+	return data[:len(data)-padding], nil
+}
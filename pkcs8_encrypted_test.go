@@ -0,0 +1,129 @@
+package jwt
+
+import (
+	"crypto/ecdsa"
+	"crypto/x509/pkix"
+	"encoding/asn1"
+	"encoding/pem"
+	"io/ioutil"
+	"math"
+	"testing"
+
+	"github.com/gotoxu/assert"
+)
+
+func TestEncryptedPKCS8RoundTrip(t *testing.T) {
+	rsaKey := loadRSAPrivateKeyFromDisk("test/sample_key")
+	ecKey, _ := GenerateKeyForMethod(ES256)
+	edKey, _ := GenerateEd25519Key()
+	password := []byte("password")
+
+	var opts = []*EncryptionOptions{
+		{Iterations: 1000},
+		{Iterations: 1000, Cipher: AES128CBC},
+		{Iterations: 1000, Cipher: AES256GCM},
+		{KDF: Scrypt, ScryptN: 1024, Cipher: AES192CBC},
+		{KDF: Scrypt, ScryptN: 1024, Cipher: AES128GCM},
+	}
+
+	for _, key := range []interface{}{rsaKey, ecKey, edKey} {
+		for _, opt := range opts {
+			data, err := MarshalEncryptedPrivateKeyToPEM(key, password, opt)
+			assert.Nil(t, err)
+
+			block, _ := pem.Decode(data)
+			assert.DeepEqual(t, block.Type, "ENCRYPTED PRIVATE KEY")
+
+			parsed, err := ParseEncryptedPrivateKeyFromPEM(data, password)
+			assert.Nil(t, err)
+			assert.DeepEqual(t, parsed, key)
+
+			_, err = ParseEncryptedPrivateKeyFromPEM(data, []byte("wrong"))
+			assert.DeepEqual(t, err, ErrPKCS8Decryption)
+		}
+	}
+
+	_, err := MarshalEncryptedPrivateKeyToPEM(ecKey, password, &EncryptionOptions{Cipher: PBECipher(100)})
+	assert.DeepEqual(t, err, ErrUnsupportedPKCS8Algorithm)
+}
+
+func TestParseOpenSSLEncryptedPKCS8(t *testing.T) {
+	ecData, _ := ioutil.ReadFile("test/ec256-private.pem")
+	ecKey, _ := ParseECPrivateKeyFromPEM(ecData)
+	rsaKey := loadRSAPrivateKeyFromDisk("test/sample_key")
+
+	for _, file := range []string{"test/ec256-private-pbkdf2.pem", "test/ec256-private-scrypt.pem"} {
+		data, err := ioutil.ReadFile(file)
+		assert.Nil(t, err)
+
+		key, err := ParseECPrivateKeyFromPEMWithPassword(data, "password")
+		assert.Nil(t, err, file)
+		assert.True(t, key.Equal(ecKey), file)
+
+		_, err = ParseECPrivateKeyFromPEMWithPassword(data, "wrong")
+		assert.DeepEqual(t, err, ErrPKCS8Decryption, file)
+
+		_, err = ParseRSAPrivateKeyFromPEMWithPassword(data, "password")
+		assert.DeepEqual(t, err, ErrNotRSAPrivateKey, file)
+	}
+
+	data, err := ioutil.ReadFile("test/sample_key-encrypted.pem")
+	assert.Nil(t, err)
+	key, err := ParseRSAPrivateKeyFromPEMWithPassword(data, "password")
+	assert.Nil(t, err)
+	assert.DeepEqual(t, key, rsaKey)
+}
+
+func TestKeyDerivationLimits(t *testing.T) {
+	salt := make([]byte, 16)
+	pbkdf2KDF := func(iterations int) pkix.AlgorithmIdentifier {
+		params, _ := asn1.Marshal(pbkdf2Params{Salt: salt, IterationCount: iterations})
+		return pkix.AlgorithmIdentifier{Algorithm: oidPBKDF2, Parameters: asn1.RawValue{FullBytes: params}}
+	}
+	scryptKDF := func(n, r, p int) pkix.AlgorithmIdentifier {
+		params, _ := asn1.Marshal(scryptParams{Salt: salt, CostParameter: n, BlockSize: r, ParallelizationParameter: p})
+		return pkix.AlgorithmIdentifier{Algorithm: oidScrypt, Parameters: asn1.RawValue{FullBytes: params}}
+	}
+
+	var tests = []struct {
+		name string
+		kdf  pkix.AlgorithmIdentifier
+		err  error
+	}{
+		{"pbkdf2", pbkdf2KDF(1000), nil},
+		{"pbkdf2 zero iterations", pbkdf2KDF(0), ErrUnsupportedPKCS8Algorithm},
+		{"pbkdf2 too many iterations", pbkdf2KDF(maxPBKDF2Iterations + 1), ErrUnsupportedPKCS8Algorithm},
+		{"pbkdf2 max int iterations", pbkdf2KDF(math.MaxInt32), ErrUnsupportedPKCS8Algorithm},
+		{"scrypt", scryptKDF(1024, 8, 1), nil},
+		{"scrypt huge n", scryptKDF(1<<30, 8, 1), ErrUnsupportedPKCS8Algorithm},
+		{"scrypt huge r", scryptKDF(1024, 1<<30, 1), ErrUnsupportedPKCS8Algorithm},
+		{"scrypt huge p", scryptKDF(1024, 8, 1<<30), ErrUnsupportedPKCS8Algorithm},
+		// 每个参数都不大，但n*r*p超过上限
+		{"scrypt cost", scryptKDF(1<<16, 16, 64), ErrUnsupportedPKCS8Algorithm},
+		{"scrypt max int", scryptKDF(math.MaxInt32, math.MaxInt32, math.MaxInt32), ErrUnsupportedPKCS8Algorithm},
+	}
+
+	for _, test := range tests {
+		_, err := deriveKey(test.kdf, []byte("password"), 16)
+		assert.DeepEqual(t, err, test.err, test.name)
+	}
+
+	key, _ := GenerateKeyForMethod(ES256)
+	_, err := EncryptPKCS8PrivateKey(key, []byte("password"), &EncryptionOptions{Iterations: maxPBKDF2Iterations + 1})
+	assert.DeepEqual(t, err, ErrUnsupportedPKCS8Algorithm)
+	_, err = EncryptPKCS8PrivateKey(key, []byte("password"), &EncryptionOptions{KDF: Scrypt, ScryptN: 1 << 16, ScryptR: 16, ScryptP: 64})
+	assert.DeepEqual(t, err, ErrUnsupportedPKCS8Algorithm)
+}
+
+func TestParsePrivateKeyFromPEMWithPassword(t *testing.T) {
+	data, _ := ioutil.ReadFile("test/ec256-private-scrypt.pem")
+
+	_, _, err := ParsePrivateKeyFromPEM(data)
+	assert.DeepEqual(t, err, ErrEncryptedPrivateKey)
+
+	key, algs, err := ParsePrivateKeyFromPEMWithPassword(data, []byte("password"))
+	assert.Nil(t, err)
+	_, ok := key.(*ecdsa.PrivateKey)
+	assert.True(t, ok)
+	assert.DeepEqual(t, algs, []string{"ES256"})
+}
@@ -0,0 +1,92 @@
+package jwt
+
+import (
+	"container/heap"
+	"context"
+	"crypto/rand"
+	"sync"
+	"time"
+)
+
+// ReplayCache 用于检测jti等一次性标识是否被重复使用
+type ReplayCache interface {
+	// Seen 记录id并返回它在expiresAt之前是否已经出现过
+	Seen(id string, expiresAt time.Time) bool
+}
+
+// ContextReplayCache 可以由访问外部存储的ReplayCache实现，
+// 验证器在有ctx时会调用SeenContext代替Seen，存储出错时令牌被拒绝
+type ContextReplayCache interface {
+	ReplayCache
+	SeenContext(ctx context.Context, id string, expiresAt time.Time) (bool, error)
+}
+
+// MemoryReplayCache 是基于内存的ReplayCache实现，过期的记录会被自动清理
+type MemoryReplayCache struct {
+	mu      sync.Mutex
+	entries map[string]time.Time
+	// expiry 是按过期时间排序的最小堆，每次只需要清理堆顶已经过期的记录
+	expiry replayHeap
+}
+
+// replayEntry 是expiry中的一条记录
+type replayEntry struct {
+	id        string
+	expiresAt time.Time
+}
+
+// replayHeap 实现heap.Interface
+type replayHeap []replayEntry
+
+func (h replayHeap) Len() int            { return len(h) }
+func (h replayHeap) Less(i, j int) bool  { return h[i].expiresAt.Before(h[j].expiresAt) }
+func (h replayHeap) Swap(i, j int)       { h[i], h[j] = h[j], h[i] }
+func (h *replayHeap) Push(x interface{}) { *h = append(*h, x.(replayEntry)) }
+func (h *replayHeap) Pop() interface{} {
+	old := *h
+	e := old[len(old)-1]
+	*h = old[:len(old)-1]
+	return e
+}
+
+// NewMemoryReplayCache 创建一个基于内存的ReplayCache
+func NewMemoryReplayCache() *MemoryReplayCache {
+	return &MemoryReplayCache{entries: map[string]time.Time{}}
+}
+
+// Seen 实现ReplayCache接口
+func (c *MemoryReplayCache) Seen(id string, expiresAt time.Time) bool {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	now := TimeFunc()
+	for len(c.expiry) > 0 && now.After(c.expiry[0].expiresAt) {
+		e := heap.Pop(&c.expiry).(replayEntry)
+		delete(c.entries, e.id)
+	}
+
+	if _, ok := c.entries[id]; ok {
+		return true
+	}
+	c.entries[id] = expiresAt
+	heap.Push(&c.expiry, replayEntry{id: id, expiresAt: expiresAt})
+	return false
+}
+
+// newTokenID 生成一个随机的jti
+func newTokenID() (string, error) {
+	b := make([]byte, 16)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	return EncodeSegment(b), nil
+}
+
+// replaySeen 优先使用ContextReplayCache，出错时视为已出现过
+func replaySeen(ctx context.Context, cache ReplayCache, id string, expiresAt time.Time) bool {
+	if cc, ok := cache.(ContextReplayCache); ok {
+		seen, err := cc.SeenContext(ctx, id, expiresAt)
+		return seen || err != nil
+	}
+	return cache.Seen(id, expiresAt)
+}
@@ -0,0 +1,30 @@
+package jwt
+
+import (
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/gotoxu/assert"
+)
+
+func TestMemoryReplayCache(t *testing.T) {
+	defer func() { TimeFunc = time.Now }()
+	now := time.Now()
+	TimeFunc = func() time.Time { return now }
+
+	cache := NewMemoryReplayCache()
+	for i := 0; i < 100; i++ {
+		assert.False(t, cache.Seen(fmt.Sprint(i), now.Add(time.Duration(i)*time.Second)))
+	}
+	assert.True(t, cache.Seen("50", now.Add(time.Hour)))
+
+	// 过期的记录被清理，同一个id可以再次使用
+	now = now.Add(50*time.Second + time.Millisecond)
+	assert.False(t, cache.Seen("new", now.Add(time.Minute)))
+	assert.Len(t, cache.entries, 50)
+	assert.Len(t, cache.expiry, 50)
+	assert.False(t, cache.Seen("10", now.Add(time.Minute)))
+	assert.True(t, cache.Seen("10", now.Add(time.Minute)))
+	assert.True(t, cache.Seen("99", now.Add(time.Minute)))
+}
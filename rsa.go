@@ -37,6 +37,10 @@ func (m *RSAMethod) Algorithm() string {
 
 // Verify 基于RSA算法验证签名
 func (m *RSAMethod) Verify(signingString, signature string, key interface{}) error {
+	return m.verifyWithPolicy(signingString, signature, key, defaultKeyPolicy)
+}
+
+func (m *RSAMethod) verifyWithPolicy(signingString, signature string, key interface{}, policy *KeyPolicy) error {
 	var err error
 
 	var sig []byte
@@ -51,6 +55,10 @@ func (m *RSAMethod) Verify(signingString, signature string, key interface{}) err
 		return ErrInvalidKeyType
 	}
 
+	if err = policy.Check(m, rsaKey); err != nil {
+		return err
+	}
+
 	if !m.Hash.Available() {
 		return ErrHashUnavailable
 	}
@@ -70,6 +78,10 @@ func (m *RSAMethod) Sign(signingString string, key interface{}) (string, error)
 		return "", ErrInvalidKeyType
 	}
 
+	if err := defaultKeyPolicy.Check(m, rsaKey); err != nil {
+		return "", err
+	}
+
 	if !m.Hash.Available() {
 		return "", ErrHashUnavailable
 	}
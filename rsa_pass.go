@@ -41,6 +41,10 @@ func init() {
 
 // Verify 实现签名验证方法
 func (m *RSAPSSMethod) Verify(signingString, siguature string, key interface{}) error {
+	return m.verifyWithPolicy(signingString, siguature, key, defaultKeyPolicy)
+}
+
+func (m *RSAPSSMethod) verifyWithPolicy(signingString, siguature string, key interface{}, policy *KeyPolicy) error {
 	var err error
 
 	var sig []byte
@@ -56,6 +60,10 @@ func (m *RSAPSSMethod) Verify(signingString, siguature string, key interface{})
 		return ErrInvalidKeyType
 	}
 
+	if err = policy.Check(m, rsaKey); err != nil {
+		return err
+	}
+
 	if !m.Hash.Available() {
 		return ErrHashUnavailable
 	}
@@ -77,6 +85,10 @@ func (m *RSAPSSMethod) Sign(signingString string, key interface{}) (string, erro
 		return "", ErrInvalidKeyType
 	}
 
+	if err := defaultKeyPolicy.Check(m, rsaKey); err != nil {
+		return "", err
+	}
+
 	if !m.Hash.Available() {
 		return "", ErrHashUnavailable
 	}
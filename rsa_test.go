@@ -97,10 +97,14 @@ func TestRSAKeyParsing(t *testing.T) {
 	_, e = ParseRSAPrivateKeyFromPEM(badKey)
 	assert.NotNil(t, e)
 
+	// 传统的PEM加密需要显式调用ParseRSAPrivateKeyFromLegacyPEM
 	_, e = ParseRSAPrivateKeyFromPEMWithPassword(secureKey, "password")
+	assert.DeepEqual(t, e, ErrLegacyPEMEncryption)
+
+	_, e = ParseRSAPrivateKeyFromLegacyPEM(secureKey, "password")
 	assert.Nil(t, e)
 
-	_, e = ParseRSAPrivateKeyFromPEMWithPassword(secureKey, "123132")
+	_, e = ParseRSAPrivateKeyFromLegacyPEM(secureKey, "123132")
 	assert.NotNil(t, e)
 
 	_, e = ParseRSAPublicKeyFromPEM(pubKey)
@@ -39,7 +39,8 @@ func ParseRSAPrivateKeyFromPEM(key []byte) (*rsa.PrivateKey, error) {
 	return pkey, nil
 }
 
-// ParseRSAPrivateKeyFromPEMWithPassword 将字节数组转换为RSA私钥，支持密码
+// ParseRSAPrivateKeyFromPEMWithPassword 将字节数组转换为RSA私钥，支持PBES2加密的PKCS8。
+// 传统的PEM加密返回ErrLegacyPEMEncryption，需要显式调用ParseRSAPrivateKeyFromLegacyPEM
 func ParseRSAPrivateKeyFromPEMWithPassword(key []byte, password string) (*rsa.PrivateKey, error) {
 	var err error
 
@@ -48,17 +49,13 @@ func ParseRSAPrivateKeyFromPEMWithPassword(key []byte, password string) (*rsa.Pr
 		return nil, ErrKeyMustBePEMEncoded
 	}
 
-	var parsedKey interface{}
-	var blockDecrypted []byte
-
-	if blockDecrypted, err = x509.DecryptPEMBlock(block, []byte(password)); err != nil {
-		return nil, err
+	if block.Type != "ENCRYPTED PRIVATE KEY" {
+		return nil, ErrLegacyPEMEncryption
 	}
 
-	if parsedKey, err = x509.ParsePKCS1PrivateKey(blockDecrypted); err != nil {
-		if parsedKey, err = x509.ParsePKCS8PrivateKey(blockDecrypted); err != nil {
-			return nil, err
-		}
+	var parsedKey interface{}
+	if parsedKey, err = DecryptPKCS8PrivateKey(block.Bytes, []byte(password)); err != nil {
+		return nil, err
 	}
 
 	var pkey *rsa.PrivateKey
@@ -96,3 +93,25 @@ func ParseRSAPublicKeyFromPEM(key []byte) (*rsa.PublicKey, error) {
 
 	return pkey, nil
 }
+
+// ParseRSAPrivateKeyFromLegacyPEM 解密RFC 1423格式(Proc-Type: 4,ENCRYPTED)的PEM块并转换为RSA私钥。
+// 这种格式不安全，只应用于迁移旧的密钥文件
+func ParseRSAPrivateKeyFromLegacyPEM(key []byte, password string) (*rsa.PrivateKey, error) {
+	decrypted, err := decryptLegacyPEM(key, password)
+	if err != nil {
+		return nil, err
+	}
+
+	var parsedKey interface{}
+	if parsedKey, err = x509.ParsePKCS1PrivateKey(decrypted); err != nil {
+		if parsedKey, err = x509.ParsePKCS8PrivateKey(decrypted); err != nil {
+			return nil, err
+		}
+	}
+
+	pkey, ok := parsedKey.(*rsa.PrivateKey)
+	if !ok {
+		return nil, ErrNotRSAPrivateKey
+	}
+	return pkey, nil
+}
@@ -0,0 +1,98 @@
+package jwt
+
+import (
+	"encoding/base64"
+	"strings"
+	"testing"
+
+	"github.com/gotoxu/assert"
+)
+
+func TestDecodeSegmentStrict(t *testing.T) {
+	var tests = []struct {
+		seg    string
+		result string
+		valid  bool
+	}{
+		{"YQ", "a", true},
+		{"YWI", "ab", true},
+		{"YWJj", "abc", true},
+		{"YR", "", false},
+		{"YWJ", "", false},
+		{"YQ==", "", false},
+		{"YW\nI", "", false},
+		{"-_-_", "\xfb\xff\xbf", true},
+		{"+/+/", "", false},
+	}
+
+	for _, test := range tests {
+		data, err := DecodeSegmentStrict(test.seg)
+		if !test.valid {
+			assert.NotNil(t, err, test.seg)
+			continue
+		}
+		assert.Nil(t, err, test.seg)
+		assert.DeepEqual(t, string(data), test.result, test.seg)
+	}
+
+	// DecodeSegment保持宽松
+	data, err := DecodeSegment("YR")
+	assert.Nil(t, err)
+	assert.DeepEqual(t, string(data), "a")
+}
+
+func TestParserStrict(t *testing.T) {
+	key := []byte("this-is-a-32-byte-hs256-test-key")
+	keyFunc := func(*Token) (interface{}, error) { return key, nil }
+
+	sign := func(header, claims string) string {
+		signing := EncodeSegment([]byte(header)) + "." + EncodeSegment([]byte(claims))
+		sig, err := HS256Method.Sign(signing, key)
+		assert.Nil(t, err)
+		return signing + "." + sig
+	}
+	signSegments := func(header, claims string) string {
+		sig, _ := HS256Method.Sign(header+"."+claims, key)
+		return header + "." + claims + "." + sig
+	}
+
+	header := `{"alg":"HS256","typ":"JWT"}`
+	padded := base64.URLEncoding.EncodeToString([]byte(`{"sub":"a"}`))
+	assert.True(t, strings.HasSuffix(padded, "="))
+	nonCanonical := EncodeSegment([]byte(`{"sub":"a"}`))
+	nonCanonical = nonCanonical[:len(nonCanonical)-1] + string(nonCanonical[len(nonCanonical)-1]+1)
+
+	var tests = []struct {
+		name        string
+		token       string
+		validStrict bool
+		validLoose  bool
+	}{
+		{"plain", sign(header, `{"sub":"a","n":{"a":1,"b":[{"a":1},{"a":2}]}}`), true, true},
+		{"duplicate header", sign(`{"alg":"HS256","kid":"a","kid":"b"}`, `{}`), false, true},
+		{"duplicate claim", sign(header, `{"sub":"a","sub":"b"}`), false, true},
+		{"escaped duplicate claim", sign(header, `{"sub":"a","\u0073ub":"b"}`), false, true},
+		{"nested duplicate", sign(header, `{"n":{"a":1,"a":2}}`), false, true},
+		{"case-insensitive duplicate", sign(header, `{"sub":"a","SUB":"b"}`), false, true},
+		{"case-insensitive duplicate exp", sign(header, `{"exp":1,"EXP":9999999999}`), false, false},
+		{"long s duplicate", sign(header, `{"sk":1,"\u017fk":2}`), false, true},
+		{"kelvin sign duplicate", sign(header, `{"\u212aey":1,"key":2}`), false, true},
+		{"trailing object", sign(header, `{"sub":"a"}{"sub":"b"}`), false, true},
+		{"padded segment", signSegments(EncodeSegment([]byte(header)), padded), false, true},
+		{"non-canonical bits", signSegments(EncodeSegment([]byte(header)), nonCanonical), false, true},
+		{"padded signature", sign(header, `{}`) + "=", false, true},
+	}
+
+	for _, test := range tests {
+		_, err := (&Parser{Strict: true}).Parse(test.token, keyFunc)
+		if test.validStrict {
+			assert.Nil(t, err, test.name)
+		} else {
+			assert.NotNil(t, err, test.name)
+			assert.DeepEqual(t, err.(*ValidationError).Errors, ValidationErrorMalformed, test.name)
+		}
+
+		_, err = new(Parser).Parse(test.token, keyFunc)
+		assert.DeepEqual(t, err == nil, test.validLoose, test.name)
+	}
+}
@@ -1,6 +1,7 @@
 package jwt
 
 import (
+	"context"
 	"encoding/base64"
 	"encoding/json"
 	"strings"
@@ -18,6 +19,10 @@ type Token struct {
 	Claims    Claims
 	Signature string
 	Valid     bool
+	// Key 是验证签名时使用的密钥，KeyFunc返回VerificationKeySet时为其中验证通过的密钥
+	Key interface{}
+	// Verification 是Parser验证令牌的记录，ParseUnverified不设置此字段
+	Verification *Verification
 }
 
 // New 创建一个新的Token
@@ -44,7 +49,10 @@ func (t *Token) Generate(key interface{}) (string, error) {
 	if sstr, err = t.CanonicalizeString(); err != nil {
 		return "", err
 	}
-	if sig, err = t.Method.Sign(sstr, key); err != nil {
+	run := startHooks(context.Background(), registeredHooks(), OpSign, t, 0)
+	sig, err = t.Method.Sign(sstr, key)
+	run.end(t, err)
+	if err != nil {
 		return "", err
 	}
 	return strings.Join([]string{sstr, sig}, "."), nil
@@ -85,6 +93,15 @@ func DecodeSegment(seg string) ([]byte, error) {
 	return base64.URLEncoding.DecodeString(seg)
 }
 
+// DecodeSegmentStrict 与DecodeSegment相同，但只接受规范的无填充base64url编码：
+// 拒绝"="填充、标准base64字符、换行以及末尾非零的填充位
+func DecodeSegmentStrict(seg string) ([]byte, error) {
+	if i := strings.IndexAny(seg, "=\r\n"); i >= 0 {
+		return nil, base64.CorruptInputError(i)
+	}
+	return base64.RawURLEncoding.Strict().DecodeString(seg)
+}
+
 // Parse 解析一个jwt令牌字符串
 func Parse(tokenString string, keyFunc KeyFunc) (*Token, error) {
 	return new(Parser).Parse(tokenString, keyFunc)
@@ -94,3 +111,13 @@ func Parse(tokenString string, keyFunc KeyFunc) (*Token, error) {
 func ParseWithClaims(tokenString string, claims Claims, keyFunc KeyFunc) (*Token, error) {
 	return new(Parser).ParseWithClaims(tokenString, claims, keyFunc)
 }
+
+// ParseWithContext 使用ctx解析一个jwt令牌字符串
+func ParseWithContext(ctx context.Context, tokenString string, keyFunc KeyFuncContext) (*Token, error) {
+	return new(Parser).ParseWithContext(ctx, tokenString, keyFunc)
+}
+
+// ParseWithClaimsContext 使用ctx解析jwt令牌字符串
+func ParseWithClaimsContext(ctx context.Context, tokenString string, claims Claims, keyFunc KeyFuncContext) (*Token, error) {
+	return new(Parser).ParseWithClaimsContext(ctx, tokenString, claims, keyFunc)
+}
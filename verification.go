@@ -0,0 +1,96 @@
+package jwt
+
+import (
+	"context"
+	"time"
+)
+
+// Verification.Checks 中记录的检查项
+const (
+	CheckLimits       = "limits"
+	CheckStrict       = "strict"
+	CheckMethod       = "alg"
+	CheckHeader       = "header"
+	CheckKey          = "key"
+	CheckSignature    = "signature"
+	CheckClaims       = "claims"
+	CheckIssuerConfig = "issuer_config"
+	CheckAccessToken  = "access_token"
+	CheckIDToken      = "id_token"
+)
+
+// Verification 记录Parser验证令牌的过程，用于审计日志。
+// 验证失败时也会记录，Checks只包含已经通过的检查
+type Verification struct {
+	// KeyID 是令牌头部的kid
+	KeyID string
+	// Thumbprint 是验证签名的公钥的RFC 7638 JWK指纹，对称密钥不记录指纹
+	Thumbprint string
+	// Algorithm 是验证签名使用的算法
+	Algorithm string
+	// Time 是开始验证时TimeFunc返回的时间
+	Time time.Time
+	// Checks 是按顺序通过的检查
+	Checks []string
+}
+
+// Passed 判断某项检查是否已经通过
+func (v *Verification) Passed(check string) bool {
+	if v == nil {
+		return false
+	}
+	for _, c := range v.Checks {
+		if c == check {
+			return true
+		}
+	}
+	return false
+}
+
+func (v *Verification) pass(check string) {
+	if v == nil {
+		return
+	}
+	v.Checks = append(v.Checks, check)
+}
+
+// newVerification 使用令牌头部初始化验证记录
+func (p *Parser) newVerification(token *Token) *Verification {
+	v := &Verification{Time: TimeFunc()}
+	v.KeyID, _ = token.Header["kid"].(string)
+	if token.Method != nil {
+		v.Algorithm = token.Method.Algorithm()
+	}
+	if p.Limits != nil {
+		v.pass(CheckLimits)
+	}
+	if p.Strict {
+		v.pass(CheckStrict)
+	}
+	return v
+}
+
+// keyThumbprint 返回非对称密钥公钥部分的JWK指纹，无法计算时返回空字符串
+func keyThumbprint(key interface{}) string {
+	if _, ok := key.([]byte); ok {
+		return ""
+	}
+	jwk, err := NewJSONWebKey(key)
+	if err != nil {
+		return ""
+	}
+	thumbprint, err := jwk.Public().Thumbprint()
+	if err != nil {
+		return ""
+	}
+	return thumbprint
+}
+
+// VerificationFromContext 返回Middleware放入Context中的令牌的验证记录
+func VerificationFromContext(ctx context.Context) (*Verification, bool) {
+	token, ok := TokenFromContext(ctx)
+	if !ok || token.Verification == nil {
+		return nil, false
+	}
+	return token.Verification, true
+}
@@ -0,0 +1,49 @@
+package jwt
+
+import (
+	"testing"
+	"time"
+
+	"github.com/gotoxu/assert"
+)
+
+func TestVerification(t *testing.T) {
+	defer func() { TimeFunc = time.Now }()
+	now := time.Unix(1600000000, 0)
+	TimeFunc = func() time.Time { return now }
+
+	privateKey := loadRSAPrivateKeyFromDisk("test/sample_key")
+	publicKey := loadRSAPublicKeyFromDisk("test/sample_key.pub")
+	jwk, _ := NewJSONWebKey(publicKey)
+	thumbprint, _ := jwk.Thumbprint()
+
+	token := NewWithClaims(RS256, MapClaims{"sub": "alice"})
+	token.Header["kid"] = "sample"
+	tokenString, err := token.Generate(privateKey)
+	assert.Nil(t, err)
+
+	p := &Parser{ValidMethods: []string{"RS256"}, Limits: DefaultLimits(), Strict: true}
+	parsed, err := p.Parse(tokenString, func(*Token) (interface{}, error) { return publicKey, nil })
+	assert.Nil(t, err)
+	assert.DeepEqual(t, parsed.Verification, &Verification{
+		KeyID:      "sample",
+		Thumbprint: thumbprint,
+		Algorithm:  "RS256",
+		Time:       now,
+		Checks:     []string{CheckLimits, CheckStrict, CheckMethod, CheckHeader, CheckKey, CheckClaims, CheckSignature},
+	})
+
+	// 对称密钥不记录指纹，失败的检查不记录
+	key := []byte("this-is-a-32-byte-hs256-test-key")
+	tokenString, _ = NewWithClaims(HS256Method, MapClaims{"exp": now.Add(-time.Minute).Unix()}).Generate(key)
+	parsed, err = Parse(tokenString, func(*Token) (interface{}, error) { return key, nil })
+	assert.NotNil(t, err)
+	assert.Empty(t, parsed.Verification.Thumbprint)
+	assert.DeepEqual(t, parsed.Verification.Checks, []string{CheckHeader, CheckKey, CheckSignature})
+	assert.False(t, parsed.Verification.Passed(CheckClaims))
+
+	parsed, _, err = new(Parser).ParseUnverified(tokenString, MapClaims{})
+	assert.Nil(t, err)
+	assert.True(t, parsed.Verification == nil)
+	assert.False(t, parsed.Verification.Passed(CheckSignature))
+}
@@ -0,0 +1,247 @@
+package jwt
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+// errors
+var (
+	ErrIssuerConfigInvalid = errors.New("verifier: issuer config requires an issuer, valid methods and a KeyFunc or KeyFuncContext")
+	ErrUntrustedIssuer     = errors.New("verifier: token issuer is not trusted")
+)
+
+// IssuerConfig 是Verifier中一个签发者的验证配置
+type IssuerConfig struct {
+	Issuer string
+	// ValidMethods 是该签发者允许的签名算法，不能为空
+	ValidMethods []string
+	KeyFunc      KeyFunc
+	// KeyFuncContext 不为空时代替KeyFunc
+	KeyFuncContext KeyFuncContext
+	// Audiences 不为空时令牌的aud必须包含其中之一
+	Audiences []string
+	// Leeway 是验证exp、nbf和iat时允许的时钟偏差
+	Leeway time.Duration
+}
+
+// Verifier 根据令牌中未经验证的iss选择签发者配置并验证令牌，
+// 未知的签发者在查找密钥之前就会被拒绝。配置可以在运行时添加和删除
+type Verifier struct {
+	// Parser 是读取iss和验证令牌时使用的Parser模板，Limits、Strict、CriticalHeaders、
+	// ValidTypes等设置对两次解析都生效。ValidMethods和SkipClaimsValidation由签发者配置决定。
+	// 为nil时使用默认的Parser
+	Parser *Parser
+
+	mu      sync.RWMutex
+	issuers map[string]IssuerConfig
+}
+
+// NewVerifier 使用给定的签发者配置创建Verifier
+func NewVerifier(configs ...IssuerConfig) (*Verifier, error) {
+	v := &Verifier{}
+	for _, config := range configs {
+		if err := v.Add(config); err != nil {
+			return nil, err
+		}
+	}
+	return v, nil
+}
+
+// Add 添加或替换一个签发者的配置
+func (v *Verifier) Add(config IssuerConfig) error {
+	if config.Issuer == "" || len(config.ValidMethods) == 0 || (config.KeyFunc == nil && config.KeyFuncContext == nil) {
+		return ErrIssuerConfigInvalid
+	}
+
+	v.mu.Lock()
+	defer v.mu.Unlock()
+
+	if v.issuers == nil {
+		v.issuers = make(map[string]IssuerConfig)
+	}
+	v.issuers[config.Issuer] = config
+	return nil
+}
+
+// Remove 删除一个签发者的配置
+func (v *Verifier) Remove(issuer string) {
+	v.mu.Lock()
+	defer v.mu.Unlock()
+
+	delete(v.issuers, issuer)
+}
+
+// Issuers 返回所有已配置的签发者
+func (v *Verifier) Issuers() []string {
+	v.mu.RLock()
+	defer v.mu.RUnlock()
+
+	issuers := make([]string, 0, len(v.issuers))
+	for issuer := range v.issuers {
+		issuers = append(issuers, issuer)
+	}
+	sort.Strings(issuers)
+	return issuers
+}
+
+// Verify 使用MapClaims验证令牌
+func (v *Verifier) Verify(tokenString string) (*Token, error) {
+	return v.VerifyWithClaims(tokenString, MapClaims{})
+}
+
+// VerifyContext 与Verify相同，ctx会传递给签发者的KeyFuncContext
+func (v *Verifier) VerifyContext(ctx context.Context, tokenString string) (*Token, error) {
+	return v.VerifyWithClaimsContext(ctx, tokenString, MapClaims{})
+}
+
+// VerifyWithClaims 验证令牌并将载荷解码到claims中。
+// exp、nbf、iat、iss和aud按照签发者配置(包括Leeway)验证，claims自身的Valid方法不会被调用
+func (v *Verifier) VerifyWithClaims(tokenString string, claims Claims) (*Token, error) {
+	return v.VerifyWithClaimsContext(context.Background(), tokenString, claims)
+}
+
+// VerifyWithClaimsContext 与VerifyWithClaims相同，ctx会传递给签发者的KeyFuncContext。
+// Hook中的OpParse覆盖整个验证过程，未知签发者和签发者配置验证失败都会作为失败报告
+func (v *Verifier) VerifyWithClaimsContext(ctx context.Context, tokenString string, claims Claims) (*Token, error) {
+	base := v.Parser
+	if base == nil {
+		base = new(Parser)
+	}
+
+	hooks := base.hooks()
+	run := startHooks(ctx, hooks, OpParse, nil, ValidationErrorMalformed)
+	token, err := v.verify(ctx, base, hooks, tokenString, claims)
+	run.setRaw(tokenString)
+	run.end(token, err)
+	return token, err
+}
+
+func (v *Verifier) verify(ctx context.Context, base *Parser, hooks []Hook, tokenString string, claims Claims) (*Token, error) {
+	unverified, _, err := base.ParseUnverified(tokenString, MapClaims{})
+	if err != nil {
+		return unverified, err
+	}
+
+	iss, _ := unverified.Claims.(MapClaims)["iss"].(string)
+	v.mu.RLock()
+	config, ok := v.issuers[iss]
+	v.mu.RUnlock()
+	if !ok {
+		return unverified, &ValidationError{Inner: ErrUntrustedIssuer, Errors: ValidationErrorIssuer}
+	}
+
+	parser := *base
+	parser.ValidMethods = config.ValidMethods
+	parser.SkipClaimsValidation = true
+	keyFunc := config.KeyFuncContext
+	if keyFunc == nil {
+		keyFunc = ContextKeyFunc(config.KeyFunc)
+	}
+	token, err := parser.parseWithClaims(ctx, hooks, tokenString, claims, keyFunc)
+	if err != nil {
+		return token, err
+	}
+
+	run := startHooks(ctx, hooks, OpClaims, token, ValidationErrorClaimsInvalid)
+	err = config.validate(token)
+	run.end(token, err)
+	if err != nil {
+		token.Valid = false
+		return token, err
+	}
+	token.Verification.pass(CheckIssuerConfig)
+	return token, nil
+}
+
+// validate 使用Leeway验证令牌的注册claims
+func (c *IssuerConfig) validate(token *Token) error {
+	registered, err := decodeRegisteredClaims(token.Raw)
+	if err != nil {
+		return &ValidationError{Inner: err, Errors: ValidationErrorMalformed}
+	}
+
+	now := TimeFunc()
+	vErr := new(ValidationError)
+
+	if registered.Issuer != c.Issuer {
+		vErr.Inner = ErrUntrustedIssuer
+		vErr.Errors |= ValidationErrorIssuer
+	}
+
+	if exp, ok := numericDate(registered.ExpiresAt); ok && now.After(exp.Add(c.Leeway)) {
+		vErr.Inner = fmt.Errorf("token is expired by %v", now.Sub(exp))
+		vErr.Errors |= ValidationErrorExpired
+	}
+
+	if nbf, ok := numericDate(registered.NotBefore); ok && now.Add(c.Leeway).Before(nbf) {
+		vErr.Inner = fmt.Errorf("token is not valid yet")
+		vErr.Errors |= ValidationErrorNotValidYet
+	}
+
+	if iat, ok := numericDate(registered.IssuedAt); ok && now.Add(c.Leeway).Before(iat) {
+		vErr.Inner = fmt.Errorf("token used before issued")
+		vErr.Errors |= ValidationErrorIssuedAt
+	}
+
+	if len(c.Audiences) > 0 {
+		found := false
+		for _, aud := range c.Audiences {
+			if registered.Audience.Contains(aud) {
+				found = true
+				break
+			}
+		}
+		if !found {
+			vErr.Inner = fmt.Errorf("token audience is not accepted")
+			vErr.Errors |= ValidationErrorAudience
+		}
+	}
+
+	if vErr.valid() {
+		return nil
+	}
+	return vErr
+}
+
+// registeredClaims 是从令牌原始载荷中读取的注册claims，与令牌使用的Claims类型无关
+type registeredClaims struct {
+	Issuer    string       `json:"iss"`
+	Audience  ClaimStrings `json:"aud"`
+	ExpiresAt json.Number  `json:"exp"`
+	NotBefore json.Number  `json:"nbf"`
+	IssuedAt  json.Number  `json:"iat"`
+}
+
+// numericDate 将NumericDate转换为时间，claim不存在或不是数字时返回false
+func numericDate(n json.Number) (time.Time, bool) {
+	f, err := n.Float64()
+	if err != nil {
+		return time.Time{}, false
+	}
+	return time.Unix(int64(f), 0), true
+}
+
+// decodeRegisteredClaims 解码令牌字符串的载荷部分
+func decodeRegisteredClaims(tokenString string) (*registeredClaims, error) {
+	parts := strings.Split(tokenString, ".")
+	if len(parts) != 3 {
+		return nil, errors.New("token contains an invalid number of segments")
+	}
+	payload, err := DecodeSegment(parts[1])
+	if err != nil {
+		return nil, err
+	}
+
+	claims := new(registeredClaims)
+	if err = json.Unmarshal(payload, claims); err != nil {
+		return nil, err
+	}
+	return claims, nil
+}
@@ -0,0 +1,144 @@
+package jwt
+
+import (
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gotoxu/assert"
+)
+
+func TestVerifier(t *testing.T) {
+	tenantA, _ := GenerateKeyForMethod(ES256)
+	tenantB := loadRSAPrivateKeyFromDisk("test/sample_key")
+	pubA, _ := PublicKeyOf(tenantA)
+
+	lookups := 0
+	keyA := func(*Token) (interface{}, error) { lookups++; return pubA, nil }
+	keyB := func(*Token) (interface{}, error) { lookups++; return &tenantB.PublicKey, nil }
+
+	v, err := NewVerifier(
+		IssuerConfig{Issuer: "https://a.example.com", ValidMethods: []string{"ES256"}, KeyFunc: keyA, Audiences: []string{"api"}},
+		IssuerConfig{Issuer: "https://b.example.com", ValidMethods: []string{"RS256"}, KeyFunc: keyB, Leeway: time.Minute},
+	)
+	assert.Nil(t, err)
+	assert.DeepEqual(t, v.Issuers(), []string{"https://a.example.com", "https://b.example.com"})
+
+	now := time.Now().Unix()
+	sign := func(method SigningMethod, key interface{}, claims MapClaims) string {
+		s, err := NewWithClaims(method, claims).Generate(key)
+		assert.Nil(t, err)
+		return s
+	}
+
+	var tests = []struct {
+		name    string
+		token   string
+		errors  uint32
+		lookups int
+	}{
+		{"tenant a", sign(ES256, tenantA, MapClaims{"iss": "https://a.example.com", "aud": []string{"other", "api"}, "exp": now + 60}), 0, 1},
+		{"tenant b", sign(RS256, tenantB, MapClaims{"iss": "https://b.example.com"}), 0, 1},
+		{"unknown issuer", sign(ES256, tenantA, MapClaims{"iss": "https://evil.example.com"}), ValidationErrorIssuer, 0},
+		{"missing issuer", sign(ES256, tenantA, MapClaims{}), ValidationErrorIssuer, 0},
+		{"wrong algorithm for issuer", sign(RS256, tenantB, MapClaims{"iss": "https://a.example.com", "aud": "api"}), ValidationErrorSignatureInvalid, 0},
+		{"key of other tenant", sign(ES256, tenantA, MapClaims{"iss": "https://b.example.com"}), ValidationErrorSignatureInvalid, 0},
+		{"wrong audience", sign(ES256, tenantA, MapClaims{"iss": "https://a.example.com", "aud": "other"}), ValidationErrorAudience, 1},
+		{"expired", sign(ES256, tenantA, MapClaims{"iss": "https://a.example.com", "aud": "api", "exp": now - 10}), ValidationErrorExpired, 1},
+		{"expired within leeway", sign(RS256, tenantB, MapClaims{"iss": "https://b.example.com", "exp": now - 10}), 0, 1},
+		{"not yet valid within leeway", sign(RS256, tenantB, MapClaims{"iss": "https://b.example.com", "nbf": now + 30, "iat": now + 30}), 0, 1},
+		{"not yet valid", sign(RS256, tenantB, MapClaims{"iss": "https://b.example.com", "nbf": now + 300}), ValidationErrorNotValidYet, 1},
+	}
+
+	for _, test := range tests {
+		lookups = 0
+		token, err := v.Verify(test.token)
+		assert.DeepEqual(t, lookups, test.lookups, test.name)
+		if test.errors == 0 {
+			assert.Nil(t, err, test.name)
+			assert.True(t, token.Valid, test.name)
+			continue
+		}
+		ve, ok := err.(*ValidationError)
+		assert.True(t, ok, test.name)
+		assert.True(t, ve.Errors&test.errors != 0, test.name)
+	}
+
+	v.Remove("https://b.example.com")
+	_, err = v.Verify(sign(RS256, tenantB, MapClaims{"iss": "https://b.example.com"}))
+	assert.True(t, errors.Is(err.(*ValidationError).Inner, ErrUntrustedIssuer))
+
+	assert.DeepEqual(t, v.Add(IssuerConfig{Issuer: "https://c.example.com", KeyFunc: keyA}), ErrIssuerConfigInvalid)
+}
+
+func TestVerifierParser(t *testing.T) {
+	key := []byte("this-is-a-32-byte-hs256-test-key")
+	v, _ := NewVerifier(IssuerConfig{
+		Issuer:       "issuer",
+		ValidMethods: []string{"HS256"},
+		KeyFunc:      func(*Token) (interface{}, error) { return key, nil },
+	})
+
+	sign := func(header map[string]interface{}, claims MapClaims) string {
+		token := NewWithClaims(HS256Method, claims)
+		for name, value := range header {
+			token.Header[name] = value
+		}
+		s, err := token.Generate(key)
+		assert.Nil(t, err)
+		return s
+	}
+	valid := sign(nil, MapClaims{"iss": "issuer"})
+	large := sign(nil, MapClaims{"iss": "issuer", "data": strings.Repeat("x", 256)})
+	wrongType := sign(map[string]interface{}{"typ": "at+jwt"}, MapClaims{"iss": "issuer"})
+	critical := sign(map[string]interface{}{"crit": []string{"ext"}, "ext": true}, MapClaims{"iss": "issuer"})
+
+	for _, s := range []string{valid, large, wrongType} {
+		_, err := v.Verify(s)
+		assert.Nil(t, err)
+	}
+	_, err := v.Verify(critical)
+	assert.NotNil(t, err)
+
+	v.Parser = &Parser{
+		Limits:          &Limits{MaxTokenSize: 256},
+		ValidTypes:      []string{"JWT"},
+		CriticalHeaders: map[string]CriticalHeaderHandler{"ext": func(*Token, interface{}) error { return nil }},
+	}
+	var tests = []struct {
+		name   string
+		token  string
+		errors uint32
+	}{
+		{"valid", valid, 0},
+		{"too large", large, ValidationErrorMalformed},
+		{"wrong typ", wrongType, ValidationErrorHeaderType},
+		{"understood crit", critical, 0},
+	}
+
+	for _, test := range tests {
+		token, err := v.Verify(test.token)
+		if test.errors == 0 {
+			assert.Nil(t, err, test.name)
+			assert.True(t, token.Valid, test.name)
+			continue
+		}
+		assert.NotNil(t, err, test.name)
+		assert.DeepEqual(t, err.(*ValidationError).Errors, test.errors, test.name)
+	}
+}
+
+func TestVerifierWithClaims(t *testing.T) {
+	key := []byte("this-is-a-32-byte-hs256-test-key")
+	v, _ := NewVerifier(IssuerConfig{
+		Issuer:       "issuer",
+		ValidMethods: []string{"HS256"},
+		KeyFunc:      func(*Token) (interface{}, error) { return key, nil },
+	})
+
+	tokenString, _ := NewWithClaims(HS256Method, StandardClaims{Issuer: "issuer", Subject: "alice"}).Generate(key)
+	token, err := v.VerifyWithClaims(tokenString, &StandardClaims{})
+	assert.Nil(t, err)
+	assert.DeepEqual(t, token.Claims.(*StandardClaims).Subject, "alice")
+}
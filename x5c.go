@@ -0,0 +1,150 @@
+package jwt
+
+import (
+	"crypto/sha1"
+	"crypto/sha256"
+	"crypto/subtle"
+	"crypto/x509"
+	"encoding/base64"
+	"errors"
+	"strings"
+	"time"
+)
+
+// errors
+var (
+	ErrX5CNoRoots            = errors.New("x5c: no trusted roots configured")
+	ErrX5CMissing            = errors.New("x5c: token header does not contain a certificate chain")
+	ErrX5CThumbprintMismatch = errors.New("x5c: x5t or x5t#S256 does not match the leaf certificate")
+	ErrX5CIssuedAtRequired   = errors.New("x5c: token must contain iat to validate the certificate chain")
+	ErrX5CKeyMismatch        = errors.New("x5c: leaf certificate key does not match the signing method")
+)
+
+// X5CVerifier 使用令牌头部x5c中的证书链验证令牌。
+// 证书链在令牌的iat时刻必须有效，并且能够链接到Roots中的根证书
+type X5CVerifier struct {
+	// Roots 是受信任的根证书，必须设置
+	Roots *x509.CertPool
+	// Intermediates 是额外的中间证书，x5c中除叶子证书外的证书也会作为中间证书
+	Intermediates []*x509.Certificate
+	// KeyUsages 是叶子证书必须包含的扩展密钥用途，为空时不限制
+	KeyUsages []x509.ExtKeyUsage
+	// DNSName 不为空时叶子证书必须对该名称有效
+	DNSName string
+}
+
+// KeyFunc 验证x5c证书链并返回叶子证书的公钥，可以直接作为Parse的KeyFunc
+func (v *X5CVerifier) KeyFunc(token *Token) (interface{}, error) {
+	if v.Roots == nil {
+		return nil, ErrX5CNoRoots
+	}
+
+	chain, err := ParseX5C(token.Header)
+	if err != nil {
+		return nil, err
+	}
+	leaf := chain[0]
+
+	if err = verifyX5T(token.Header, leaf); err != nil {
+		return nil, err
+	}
+	if !KeyMatchesMethod(token.Method, leaf.PublicKey) {
+		return nil, ErrX5CKeyMismatch
+	}
+
+	iat, err := tokenIssuedAt(token)
+	if err != nil {
+		return nil, err
+	}
+
+	intermediates := x509.NewCertPool()
+	for _, cert := range v.Intermediates {
+		intermediates.AddCert(cert)
+	}
+	for _, cert := range chain[1:] {
+		intermediates.AddCert(cert)
+	}
+
+	usages := v.KeyUsages
+	if len(usages) == 0 {
+		usages = []x509.ExtKeyUsage{x509.ExtKeyUsageAny}
+	}
+
+	if _, err = leaf.Verify(x509.VerifyOptions{
+		Roots:         v.Roots,
+		Intermediates: intermediates,
+		DNSName:       v.DNSName,
+		CurrentTime:   iat,
+		KeyUsages:     usages,
+	}); err != nil {
+		return nil, err
+	}
+
+	return leaf.PublicKey, nil
+}
+
+// ParseX5C 解析令牌头部中的x5c证书链，第一个证书为叶子证书
+func ParseX5C(header map[string]interface{}) ([]*x509.Certificate, error) {
+	values, ok := header["x5c"].([]interface{})
+	if !ok || len(values) == 0 {
+		return nil, ErrX5CMissing
+	}
+
+	chain := make([]*x509.Certificate, 0, len(values))
+	for _, value := range values {
+		s, ok := value.(string)
+		if !ok {
+			return nil, errors.New("x5c: certificate must be a string")
+		}
+		// x5c使用标准base64编码而不是base64url
+		der, err := base64.StdEncoding.DecodeString(s)
+		if err != nil {
+			return nil, err
+		}
+		cert, err := x509.ParseCertificate(der)
+		if err != nil {
+			return nil, err
+		}
+		chain = append(chain, cert)
+	}
+	return chain, nil
+}
+
+// verifyX5T 检查头部中的x5t和x5t#S256是否与叶子证书一致
+func verifyX5T(header map[string]interface{}, leaf *x509.Certificate) error {
+	if x5t, ok := header["x5t"]; ok {
+		sum := sha1.Sum(leaf.Raw)
+		if !thumbprintEqual(x5t, sum[:]) {
+			return ErrX5CThumbprintMismatch
+		}
+	}
+	if x5t, ok := header["x5t#S256"]; ok {
+		sum := sha256.Sum256(leaf.Raw)
+		if !thumbprintEqual(x5t, sum[:]) {
+			return ErrX5CThumbprintMismatch
+		}
+	}
+	return nil
+}
+
+func thumbprintEqual(value interface{}, sum []byte) bool {
+	s, ok := value.(string)
+	if !ok {
+		return false
+	}
+	expected := base64.RawURLEncoding.EncodeToString(sum)
+	return subtle.ConstantTimeCompare([]byte(strings.TrimRight(s, "=")), []byte(expected)) == 1
+}
+
+// tokenIssuedAt 从令牌的原始载荷中读取iat，与Claims的具体类型无关
+func tokenIssuedAt(token *Token) (time.Time, error) {
+	claims, err := decodeRegisteredClaims(token.Raw)
+	if err != nil {
+		return time.Time{}, err
+	}
+	iat, ok := numericDate(claims.IssuedAt)
+	if !ok || iat.Unix() <= 0 {
+		return time.Time{}, ErrX5CIssuedAtRequired
+	}
+	return iat, nil
+}
@@ -0,0 +1,136 @@
+package jwt
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/base64"
+	"math/big"
+	"testing"
+	"time"
+
+	"github.com/gotoxu/assert"
+)
+
+type testCert struct {
+	cert *x509.Certificate
+	key  *ecdsa.PrivateKey
+}
+
+func newTestCert(t *testing.T, name string, parent *testCert, ca bool, notBefore, notAfter time.Time, usages ...x509.ExtKeyUsage) *testCert {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	assert.Nil(t, err)
+
+	template := &x509.Certificate{
+		SerialNumber:          big.NewInt(time.Now().UnixNano()),
+		Subject:               pkix.Name{CommonName: name},
+		NotBefore:             notBefore,
+		NotAfter:              notAfter,
+		BasicConstraintsValid: true,
+		IsCA:                  ca,
+		ExtKeyUsage:           usages,
+	}
+	if ca {
+		template.KeyUsage = x509.KeyUsageCertSign
+	} else {
+		template.KeyUsage = x509.KeyUsageDigitalSignature
+		template.DNSNames = []string{name}
+	}
+
+	issuer, issuerKey := template, key
+	if parent != nil {
+		issuer, issuerKey = parent.cert, parent.key
+	}
+	der, err := x509.CreateCertificate(rand.Reader, template, issuer, &key.PublicKey, issuerKey)
+	assert.Nil(t, err)
+	cert, err := x509.ParseCertificate(der)
+	assert.Nil(t, err)
+	return &testCert{cert: cert, key: key}
+}
+
+func TestX5CVerifier(t *testing.T) {
+	now := time.Now()
+	root := newTestCert(t, "root", nil, true, now.Add(-48*time.Hour), now.Add(48*time.Hour))
+	intermediate := newTestCert(t, "intermediate", root, true, now.Add(-48*time.Hour), now.Add(48*time.Hour))
+	leaf := newTestCert(t, "signer.example.com", intermediate, false, now.Add(-time.Hour), now.Add(time.Hour), x509.ExtKeyUsageClientAuth)
+	otherRoot := newTestCert(t, "other", nil, true, now.Add(-48*time.Hour), now.Add(48*time.Hour))
+
+	roots := x509.NewCertPool()
+	roots.AddCert(root.cert)
+	otherRoots := x509.NewCertPool()
+	otherRoots.AddCert(otherRoot.cert)
+
+	x5c := func(certs ...*testCert) []interface{} {
+		var values []interface{}
+		for _, c := range certs {
+			values = append(values, base64.StdEncoding.EncodeToString(c.cert.Raw))
+		}
+		return values
+	}
+	s256 := sha256.Sum256(leaf.cert.Raw)
+
+	var tests = []struct {
+		name     string
+		verifier *X5CVerifier
+		header   map[string]interface{}
+		claims   MapClaims
+		valid    bool
+	}{
+		{"valid chain", &X5CVerifier{Roots: roots}, map[string]interface{}{"x5c": x5c(leaf, intermediate)}, MapClaims{"iat": now.Unix()}, true},
+		{"configured intermediate", &X5CVerifier{Roots: roots, Intermediates: []*x509.Certificate{intermediate.cert}}, map[string]interface{}{"x5c": x5c(leaf)}, MapClaims{"iat": now.Unix()}, true},
+		{"missing intermediate", &X5CVerifier{Roots: roots}, map[string]interface{}{"x5c": x5c(leaf)}, MapClaims{"iat": now.Unix()}, false},
+		{"untrusted root", &X5CVerifier{Roots: otherRoots}, map[string]interface{}{"x5c": x5c(leaf, intermediate)}, MapClaims{"iat": now.Unix()}, false},
+		{"no roots", &X5CVerifier{}, map[string]interface{}{"x5c": x5c(leaf, intermediate)}, MapClaims{"iat": now.Unix()}, false},
+		{"missing x5c", &X5CVerifier{Roots: roots}, map[string]interface{}{}, MapClaims{"iat": now.Unix()}, false},
+		{"missing iat", &X5CVerifier{Roots: roots}, map[string]interface{}{"x5c": x5c(leaf, intermediate)}, MapClaims{}, false},
+		{"iat before leaf validity", &X5CVerifier{Roots: roots}, map[string]interface{}{"x5c": x5c(leaf, intermediate)}, MapClaims{"iat": now.Add(-2 * time.Hour).Unix()}, false},
+		{"matching thumbprint", &X5CVerifier{Roots: roots}, map[string]interface{}{"x5c": x5c(leaf, intermediate), "x5t#S256": base64.RawURLEncoding.EncodeToString(s256[:])}, MapClaims{"iat": now.Unix()}, true},
+		{"wrong thumbprint", &X5CVerifier{Roots: roots}, map[string]interface{}{"x5c": x5c(leaf, intermediate), "x5t": "AAAA"}, MapClaims{"iat": now.Unix()}, false},
+		{"matching key usage", &X5CVerifier{Roots: roots, KeyUsages: []x509.ExtKeyUsage{x509.ExtKeyUsageClientAuth}}, map[string]interface{}{"x5c": x5c(leaf, intermediate)}, MapClaims{"iat": now.Unix()}, true},
+		{"wrong key usage", &X5CVerifier{Roots: roots, KeyUsages: []x509.ExtKeyUsage{x509.ExtKeyUsageServerAuth}}, map[string]interface{}{"x5c": x5c(leaf, intermediate)}, MapClaims{"iat": now.Unix()}, false},
+		{"matching name", &X5CVerifier{Roots: roots, DNSName: "signer.example.com"}, map[string]interface{}{"x5c": x5c(leaf, intermediate)}, MapClaims{"iat": now.Unix()}, true},
+		{"wrong name", &X5CVerifier{Roots: roots, DNSName: "other.example.com"}, map[string]interface{}{"x5c": x5c(leaf, intermediate)}, MapClaims{"iat": now.Unix()}, false},
+	}
+
+	for _, test := range tests {
+		token := NewWithClaims(ES256, test.claims)
+		for k, v := range test.header {
+			token.Header[k] = v
+		}
+		tokenString, err := token.Generate(leaf.key)
+		assert.Nil(t, err, test.name)
+
+		parsed, err := Parse(tokenString, test.verifier.KeyFunc)
+		if test.valid {
+			assert.Nil(t, err, test.name)
+			assert.True(t, parsed.Valid, test.name)
+		} else {
+			assert.NotNil(t, err, test.name)
+		}
+	}
+}
+
+func TestX5CVerifierWrongSigner(t *testing.T) {
+	now := time.Now()
+	root := newTestCert(t, "root", nil, true, now.Add(-time.Hour), now.Add(time.Hour))
+	leaf := newTestCert(t, "signer", root, false, now.Add(-time.Hour), now.Add(time.Hour))
+	roots := x509.NewCertPool()
+	roots.AddCert(root.cert)
+
+	token := NewWithClaims(ES256, MapClaims{"iat": now.Unix()})
+	token.Header["x5c"] = []interface{}{base64.StdEncoding.EncodeToString(leaf.cert.Raw)}
+	tokenString, _ := token.Generate(root.key)
+
+	_, err := Parse(tokenString, (&X5CVerifier{Roots: roots}).KeyFunc)
+	assert.NotNil(t, err)
+	assert.True(t, err.(*ValidationError).Errors&ValidationErrorSignatureInvalid != 0)
+
+	_, err = Parse(tokenString, func(tok *Token) (interface{}, error) {
+		tok.Method = RS256
+		return (&X5CVerifier{Roots: roots}).KeyFunc(tok)
+	})
+	assert.DeepEqual(t, err.(*ValidationError).Inner, ErrX5CKeyMismatch)
+}